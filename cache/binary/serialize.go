@@ -1,6 +1,11 @@
 package binary
 
-import osm "github.com/omniscale/go-osm"
+import (
+	"encoding/binary"
+	"time"
+
+	osm "github.com/omniscale/go-osm"
+)
 
 const coordFactor float64 = 11930464.7083 // ((2<<31)-1)/360.0
 
@@ -16,7 +21,11 @@ func MarshalNode(node *osm.Node) ([]byte, error) {
 	pbfNode := &Node{}
 	pbfNode.fromWgsCoord(node.Long, node.Lat)
 	pbfNode.Tags = tagsAsArray(node.Tags)
-	return pbfNode.Marshal()
+	data, err := pbfNode.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return appendMetadata(data, node.Metadata), nil
 }
 
 func UnmarshalNode(data []byte) (node *osm.Node, err error) {
@@ -29,6 +38,7 @@ func UnmarshalNode(data []byte) (node *osm.Node, err error) {
 	node = &osm.Node{}
 	node.Long, node.Lat = pbfNode.wgsCoord()
 	node.Tags = tagsFromArray(pbfNode.Tags)
+	node.Metadata = readMetadata(data)
 	return node, nil
 }
 
@@ -57,7 +67,11 @@ func MarshalWay(way *osm.Way) ([]byte, error) {
 	deltaPack(way.Refs)
 	pbfWay.Refs = way.Refs
 	pbfWay.Tags = tagsAsArray(way.Tags)
-	return pbfWay.Marshal()
+	data, err := pbfWay.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return appendMetadata(data, way.Metadata), nil
 }
 
 func UnmarshalWay(data []byte) (way *osm.Way, err error) {
@@ -71,6 +85,7 @@ func UnmarshalWay(data []byte) (way *osm.Way, err error) {
 	deltaUnpack(pbfWay.Refs)
 	way.Refs = pbfWay.Refs
 	way.Tags = tagsFromArray(pbfWay.Tags)
+	way.Metadata = readMetadata(data)
 	return way, nil
 }
 
@@ -85,7 +100,11 @@ func MarshalRelation(relation *osm.Relation) ([]byte, error) {
 		pbfRelation.MemberRoles[i] = m.Role
 	}
 	pbfRelation.Tags = tagsAsArray(relation.Tags)
-	return pbfRelation.Marshal()
+	data, err := pbfRelation.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return appendMetadata(data, relation.Metadata), nil
 }
 
 func UnmarshalRelation(data []byte) (relation *osm.Relation, err error) {
@@ -104,5 +123,85 @@ func UnmarshalRelation(data []byte) (relation *osm.Relation, err error) {
 	}
 	//relation.Nodes = pbfRelation.Node
 	relation.Tags = tagsFromArray(pbfRelation.Tags)
+	relation.Metadata = readMetadata(data)
 	return relation, nil
 }
+
+// Version and timestamp of elements are stored as additional varint fields
+// after the Node/Way/Relation message. These fields are not part of
+// messages.proto and they are skipped by the generated Unmarshal methods.
+const (
+	metadataVersionField   = 14
+	metadataTimestampField = 15
+	wireVarint             = 0
+	wireFixed64            = 1
+	wireBytes              = 2
+	wireFixed32            = 5
+)
+
+// appendMetadata appends the version and timestamp of md to the
+// marshaled message data. Returns data if md is nil.
+func appendMetadata(data []byte, md *osm.Metadata) []byte {
+	if md == nil {
+		return data
+	}
+	var buf [binary.MaxVarintLen64]byte
+	data = append(data, metadataVersionField<<3|wireVarint)
+	n := binary.PutUvarint(buf[:], uint64(md.Version))
+	data = append(data, buf[:n]...)
+	data = append(data, metadataTimestampField<<3|wireVarint)
+	n = binary.PutUvarint(buf[:], uint64(md.Timestamp.Unix()))
+	return append(data, buf[:n]...)
+}
+
+// readMetadata returns the version and timestamp from the marshaled message
+// data. Returns nil if data contains no metadata.
+func readMetadata(data []byte) *osm.Metadata {
+	var md *osm.Metadata
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return md
+		}
+		data = data[n:]
+		switch key & 7 {
+		case wireVarint:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return md
+			}
+			data = data[n:]
+			switch key >> 3 {
+			case metadataVersionField:
+				if md == nil {
+					md = &osm.Metadata{}
+				}
+				md.Version = int32(v)
+			case metadataTimestampField:
+				if md == nil {
+					md = &osm.Metadata{}
+				}
+				md.Timestamp = time.Unix(int64(v), 0).UTC()
+			}
+		case wireFixed64:
+			if len(data) < 8 {
+				return md
+			}
+			data = data[8:]
+		case wireBytes:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < l {
+				return md
+			}
+			data = data[n+int(l):]
+		case wireFixed32:
+			if len(data) < 4 {
+				return md
+			}
+			data = data[4:]
+		default:
+			return md
+		}
+	}
+	return md
+}
@@ -2,6 +2,7 @@ package binary
 
 import (
 	"testing"
+	"time"
 
 	osm "github.com/omniscale/go-osm"
 )
@@ -155,3 +156,62 @@ func TestDeltaUnpack(t *testing.T) {
 		}
 	}
 }
+
+func TestMarshalMetadata(t *testing.T) {
+	ts := time.Date(2020, 5, 4, 12, 30, 0, 0, time.UTC)
+
+	way := &osm.Way{}
+	way.Tags = osm.Tags{"highway": "trunk"}
+	way.Refs = []int64{1, 2, 3}
+	way.Metadata = &osm.Metadata{Version: 7, Timestamp: ts}
+	data, err := MarshalWay(way)
+	if err != nil {
+		t.Fatal(err)
+	}
+	way, err = UnmarshalWay(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if way.Metadata == nil || way.Metadata.Version != 7 || !way.Metadata.Timestamp.Equal(ts) {
+		t.Error("unexpected metadata", way.Metadata)
+	}
+	if way.Tags["highway"] != "trunk" || !compareRefs(way.Refs, []int64{1, 2, 3}) {
+		t.Error("unexpected way", way)
+	}
+
+	node := &osm.Node{Long: 8, Lat: 53}
+	node.Tags = osm.Tags{"amenity": "cafe"}
+	node.Metadata = &osm.Metadata{Version: 1, Timestamp: ts}
+	data, err = MarshalNode(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node, err = UnmarshalNode(data); err != nil {
+		t.Fatal(err)
+	}
+	if node.Metadata == nil || node.Metadata.Version != 1 || !node.Metadata.Timestamp.Equal(ts) {
+		t.Error("unexpected metadata", node.Metadata)
+	}
+
+	rel := &osm.Relation{Members: []osm.Member{{ID: 1, Type: osm.WayMember, Role: "outer"}}}
+	rel.Metadata = &osm.Metadata{Version: 3, Timestamp: ts}
+	data, err = MarshalRelation(rel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel, err = UnmarshalRelation(data); err != nil {
+		t.Fatal(err)
+	}
+	if rel.Metadata == nil || rel.Metadata.Version != 3 || rel.Members[0].Role != "outer" {
+		t.Error("unexpected relation", rel, rel.Metadata)
+	}
+
+	// no metadata
+	data, err = MarshalWay(&osm.Way{Refs: []int64{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if way, err = UnmarshalWay(data); err != nil || way.Metadata != nil {
+		t.Error("unexpected metadata", way.Metadata, err)
+	}
+}
@@ -74,7 +74,11 @@ func init() {
 		"int64":              &simpleColumnType{"BIGINT"},
 		"float32":            &simpleColumnType{"REAL"},
 		"hstore_string":      &simpleColumnType{"HSTORE"},
+		"timestamp":          &simpleColumnType{"TIMESTAMP WITH TIME ZONE"},
 		"geometry":           &geometryType{"GEOMETRY"},
 		"validated_geometry": &validatedGeometryType{geometryType{"GEOMETRY"}},
+		// additional geometry column, created with the table and not
+		// with AddGeometryColumn and without index
+		"simplified_geometry": &geometryType{"GEOMETRY(GEOMETRY)"},
 	}
 }
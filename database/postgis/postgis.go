@@ -462,7 +462,13 @@ func (pg *PostGIS) Open() error {
 
 func (pg *PostGIS) InsertPoint(elem osm.Element, geom geom.Geometry, matches []mapping.Match) error {
 	for _, match := range matches {
+		if !match.AcceptGeometry(&geom) {
+			continue
+		}
 		row := match.Row(&elem, &geom)
+		if row == nil {
+			continue
+		}
 		if err := pg.txRouter.Insert(match.Table.Name, row); err != nil {
 			return err
 		}
@@ -472,7 +478,13 @@ func (pg *PostGIS) InsertPoint(elem osm.Element, geom geom.Geometry, matches []m
 
 func (pg *PostGIS) InsertLineString(elem osm.Element, geom geom.Geometry, matches []mapping.Match) error {
 	for _, match := range matches {
+		if !match.AcceptGeometry(&geom) {
+			continue
+		}
 		row := match.Row(&elem, &geom)
+		if row == nil {
+			continue
+		}
 		if err := pg.txRouter.Insert(match.Table.Name, row); err != nil {
 			return err
 		}
@@ -493,7 +505,13 @@ func (pg *PostGIS) InsertLineString(elem osm.Element, geom geom.Geometry, matche
 
 func (pg *PostGIS) InsertPolygon(elem osm.Element, geom geom.Geometry, matches []mapping.Match) error {
 	for _, match := range matches {
+		if !match.AcceptGeometry(&geom) {
+			continue
+		}
 		row := match.Row(&elem, &geom)
+		if row == nil {
+			continue
+		}
 		if err := pg.txRouter.Insert(match.Table.Name, row); err != nil {
 			return err
 		}
@@ -515,6 +533,9 @@ func (pg *PostGIS) InsertPolygon(elem osm.Element, geom geom.Geometry, matches [
 func (pg *PostGIS) InsertRelationMember(rel osm.Relation, m osm.Member, mi int, geom geom.Geometry, matches []mapping.Match) error {
 	for _, match := range matches {
 		row := match.MemberRow(&rel, &m, mi, &geom)
+		if row == nil {
+			continue
+		}
 		if err := pg.txRouter.Insert(match.Table.Name, row); err != nil {
 			return err
 		}
@@ -527,6 +548,11 @@ func (pg *PostGIS) Delete(id int64, matches []mapping.Match) error {
 		if err := pg.txRouter.Delete(match.Table.Name, id); err != nil {
 			return errors.Wrapf(err, "deleting %d from %q", id, match.Table.Name)
 		}
+		if cm, ok := match.CentroidMatch(); ok {
+			if err := pg.txRouter.Delete(cm.Table.Name, id); err != nil {
+				return errors.Wrapf(err, "deleting %d from %q", id, cm.Table.Name)
+			}
+		}
 	}
 	if pg.updateGeneralizedTables {
 		for _, generalizedTable := range pg.generalizedFromMatches(matches) {
@@ -632,6 +658,10 @@ func New(conf database.Config, m *config.Mapping) (database.DB, error) {
 		if err != nil {
 			return nil, errors.Wrapf(err, "creating table spec for %q", name)
 		}
+		if table.Centroids {
+			centroidName := mapping.CentroidTableName(name)
+			db.Tables[centroidName] = NewCentroidTableSpec(db, db.Tables[name], centroidName)
+		}
 	}
 	for name, table := range m.GeneralizedTables {
 		db.GeneralizedTables[name] = NewGeneralizedTableSpec(db, table)
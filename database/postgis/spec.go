@@ -13,6 +13,7 @@ type ColumnSpec struct {
 	Name      string
 	FieldType mapping.ColumnType
 	Type      ColumnType
+	NotNull   bool
 }
 type TableSpec struct {
 	Name            string
@@ -38,6 +39,9 @@ type GeneralizedTableSpec struct {
 }
 
 func (col *ColumnSpec) AsSQL() string {
+	if col.NotNull {
+		return fmt.Sprintf("\"%s\" %s NOT NULL", col.Name, col.Type.Name())
+	}
 	return fmt.Sprintf("\"%s\" %s", col.Name, col.Type.Name())
 }
 
@@ -151,6 +155,9 @@ func NewTableSpec(pg *PostGIS, t *config.Table) (*TableSpec, error) {
 		GeometryType: geomType,
 		Srid:         pg.Config.Srid,
 	}
+	if err := mapping.CheckAreaFilter(t, spec.Srid); err != nil {
+		return nil, err
+	}
 	for _, column := range t.Columns {
 		columnType, err := mapping.MakeColumnType(column)
 		if err != nil {
@@ -160,12 +167,23 @@ func NewTableSpec(pg *PostGIS, t *config.Table) (*TableSpec, error) {
 		if !ok {
 			return nil, errors.Errorf("unhandled column type %v, using string type", columnType)
 		}
-		col := ColumnSpec{column.Name, *columnType, pgType}
+		col := ColumnSpec{column.Name, *columnType, pgType, column.NotNull}
 		spec.Columns = append(spec.Columns, col)
 	}
 	return &spec, nil
 }
 
+// NewCentroidTableSpec returns the spec for the centroids of the
+// polygon table spec. It has the same columns as the polygon table.
+func NewCentroidTableSpec(pg *PostGIS, polygonSpec *TableSpec, name string) *TableSpec {
+	spec := *polygonSpec
+	spec.Name = name
+	spec.FullName = pg.Prefix + name
+	spec.GeometryType = string(mapping.PointTable)
+	spec.Generalizations = nil
+	return &spec
+}
+
 func NewGeneralizedTableSpec(pg *PostGIS, t *config.GeneralizedTable) *GeneralizedTableSpec {
 	spec := GeneralizedTableSpec{
 		Name:       t.Name,
@@ -0,0 +1,110 @@
+package postgis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/omniscale/imposm3/database"
+	"github.com/omniscale/imposm3/mapping"
+	"github.com/omniscale/imposm3/mapping/config"
+)
+
+func TestNewTableSpecNotNull(t *testing.T) {
+	pg := &PostGIS{Config: database.Config{Srid: 3857, ImportSchema: "import"}}
+	spec, err := NewTableSpec(pg, &config.Table{
+		Name: "roads",
+		Type: "linestring",
+		Columns: []*config.Column{
+			{Name: "osm_id", Type: "id"},
+			{Name: "name", Key: "name", Type: "string", NotNull: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Columns[0].NotNull || !spec.Columns[1].NotNull {
+		t.Fatal("unexpected NotNull flags", spec.Columns)
+	}
+	if !strings.Contains(spec.CreateTableSQL(), `"name" VARCHAR NOT NULL`) {
+		t.Error("missing NOT NULL in", spec.CreateTableSQL())
+	}
+}
+
+func TestNewTableSpecSimplifiedGeometry(t *testing.T) {
+	pg := &PostGIS{Config: database.Config{Srid: 3857, ImportSchema: "import"}}
+	spec, err := NewTableSpec(pg, &config.Table{
+		Name: "roads",
+		Type: "linestring",
+		Columns: []*config.Column{
+			{Name: "osm_id", Type: "id"},
+			{Name: "geometry", Type: "geometry"},
+			{Name: "geometry_simple", Type: "simplified_geometry", Args: map[string]interface{}{"tolerance": 10}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql := spec.CreateTableSQL()
+	// geometry is added with AddGeometryColumn
+	if strings.Contains(sql, `"geometry" `) {
+		t.Error("unexpected geometry column in", sql)
+	}
+	if !strings.Contains(sql, `"geometry_simple" GEOMETRY(GEOMETRY)`) {
+		t.Error("missing simplified geometry column in", sql)
+	}
+	if !strings.Contains(spec.InsertSQL(), "$3::Geometry") {
+		t.Error("unexpected insert SQL", spec.InsertSQL())
+	}
+}
+
+func TestNewTableSpecExcludeColumns(t *testing.T) {
+	m, err := mapping.New([]byte(`
+    tables:
+      routes:
+        type: relation
+        columns: &route_columns
+        - {name: osm_id, type: id}
+        - {name: name, key: name, type: string}
+        - {name: ref, key: ref, type: string}
+        mapping:
+          route: [bus]
+      route_members:
+        type: relation_member
+        columns: *route_columns
+        exclude_columns: [ref]
+        mapping:
+          route: [bus]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg := &PostGIS{Config: database.Config{Srid: 3857, ImportSchema: "import"}}
+	spec, err := NewTableSpec(pg, m.Conf.Tables["route_members"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.Columns) != 2 || spec.Columns[0].Name != "osm_id" || spec.Columns[1].Name != "name" {
+		t.Error("unexpected columns", spec.Columns)
+	}
+	spec, err = NewTableSpec(pg, m.Conf.Tables["routes"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.Columns) != 3 {
+		t.Error("unexpected columns", spec.Columns)
+	}
+
+	_, err = mapping.New([]byte(`
+    tables:
+      route_members:
+        type: relation_member
+        columns:
+        - {name: osm_id, type: id}
+        exclude_columns: [ref]
+        mapping:
+          route: [bus]
+    `))
+	if err == nil {
+		t.Error("unknown excluded column not rejected")
+	}
+}
@@ -0,0 +1,104 @@
+package geom
+
+import (
+	"sync"
+
+	"github.com/omniscale/imposm3/geom/geos"
+)
+
+// ClipBoundary tests and clips geometries against a fixed boundary
+// (e.g. the -limitto geometry). The boundary is prepared on first use for
+// each Geos handle, as prepared geometries are not thread safe. A
+// ClipBoundary can be used concurrently, as long as each goroutine passes
+// its own Geos handle. The boundary must not be destroyed while the
+// ClipBoundary is in use. Call Destroy to free the prepared geometries.
+type ClipBoundary struct {
+	geom   *geos.Geom
+	bounds geos.Bounds
+
+	mu       *sync.Mutex
+	prepared map[*geos.Geos]*geos.PreparedGeom
+}
+
+// NewClipBoundary returns a ClipBoundary for the (Multi)Polygon boundary.
+func NewClipBoundary(boundary *geos.Geom) *ClipBoundary {
+	return &ClipBoundary{
+		geom:     boundary,
+		bounds:   boundary.Bounds(),
+		mu:       &sync.Mutex{},
+		prepared: make(map[*geos.Geos]*geos.PreparedGeom),
+	}
+}
+
+// preparedGeom returns the prepared boundary for the handle g. Returns nil
+// if the boundary could not be prepared.
+func (c *ClipBoundary) preparedGeom(g *geos.Geos) *geos.PreparedGeom {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prep, ok := c.prepared[g]
+	if !ok {
+		prep = g.Prepare(c.geom)
+		c.prepared[g] = prep
+	}
+	return prep
+}
+
+// Destroy frees the prepared geometries of all handles. The boundary
+// itself is not destroyed. The boundary is prepared again if the
+// ClipBoundary is used after Destroy.
+func (c *ClipBoundary) Destroy(g *geos.Geos) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, prep := range c.prepared {
+		if prep != nil {
+			g.PreparedDestroy(prep)
+		}
+	}
+	c.prepared = make(map[*geos.Geos]*geos.PreparedGeom)
+}
+
+// Intersects returns true if geom intersects the boundary.
+func (c *ClipBoundary) Intersects(g *geos.Geos, geom *geos.Geom) bool {
+	if !c.bounds.Intersects(geom.Bounds()) {
+		return false
+	}
+	prep := c.preparedGeom(g)
+	if prep == nil {
+		return g.Intersects(c.geom, geom)
+	}
+	return g.PreparedIntersects(prep, geom)
+}
+
+// Clip returns the intersection of geom with the boundary. Returns geom
+// itself if it is completely within the boundary and nil if it is outside.
+// geom is clipped to the envelope of the boundary with ClipByRect first (if
+// supported by GEOS), to reduce the size of the full intersection.
+func (c *ClipBoundary) Clip(g *geos.Geos, geom *geos.Geom) (*geos.Geom, error) {
+	if !c.bounds.Intersects(geom.Bounds()) {
+		return nil, nil
+	}
+	if prep := c.preparedGeom(g); prep != nil {
+		if g.PreparedContains(prep, geom) {
+			return geom, nil
+		}
+		if !g.PreparedIntersects(prep, geom) {
+			return nil, nil
+		}
+	}
+
+	if geos.Caps().ClipByRect {
+		if clipped, err := g.ClipByRect(geom, c.bounds); err == nil {
+			defer g.Destroy(clipped)
+			geom = clipped
+		}
+	}
+	result := g.Intersection(c.geom, geom)
+	if result == nil {
+		return nil, newGeometryError("unable to clip geometry", 1)
+	}
+	if g.IsEmpty(result) {
+		g.Destroy(result)
+		return nil, nil
+	}
+	return result, nil
+}
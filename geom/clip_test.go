@@ -0,0 +1,72 @@
+package geom
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/omniscale/imposm3/geom/geos"
+)
+
+func TestClipBoundary(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	// L-shaped boundary
+	boundary := g.FromWkt("POLYGON((0 0, 100 0, 100 50, 50 50, 50 100, 0 100, 0 0))")
+	clip := NewClipBoundary(boundary)
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := geos.NewGeos()
+			defer g.Finish()
+
+			for i := 0; i < 100; i++ {
+				x := float64(i)
+				// 10x10 squares along the diagonal
+				square := g.FromWkt(fmt.Sprintf("POLYGON((%[1]f %[1]f, %[2]f %[1]f, %[2]f %[2]f, %[1]f %[2]f, %[1]f %[1]f))", x, x+10))
+				clipped, err := clip.Clip(g, square)
+				if err != nil {
+					t.Error(err)
+					continue
+				}
+				expected := g.Intersection(boundary, square)
+				if g.IsEmpty(expected) {
+					if clipped != nil || clip.Intersects(g, square) {
+						t.Errorf("square at %v not outside", x)
+					}
+					continue
+				}
+				if clipped == nil || !clip.Intersects(g, square) {
+					t.Errorf("square at %v not intersecting", x)
+					continue
+				}
+				if d := clipped.Area() - expected.Area(); d > 1e-9 || d < -1e-9 {
+					t.Errorf("unexpected area %v for square at %v", clipped.Area(), x)
+				}
+				if x+10 <= 50 && clipped != square {
+					t.Errorf("contained square at %v not returned unchanged", x)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	clip.Destroy(g)
+	if !g.IsValid(boundary) || boundary.Area() != 7500 {
+		t.Fatal("boundary destroyed", g.AsWkt(boundary))
+	}
+	// boundary is prepared again after Destroy
+	square := g.FromWkt("POLYGON((90 0, 110 0, 110 10, 90 10, 90 0))")
+	clipped, err := clip.Clip(g, square)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clipped == nil || clipped.Area() != 100 {
+		t.Error("unexpected clip result after Destroy", g.AsWkt(clipped))
+	}
+	clip.Destroy(g)
+}
@@ -33,13 +33,17 @@ func newGeometryError(message string, level int) *GeometryError {
 var (
 	ErrorOneNodeWay = newGeometryError("need at least two separate nodes for way", 0)
 	ErrorNoRing     = newGeometryError("linestrings do not form ring", 0)
+	ErrorZeroArea   = newGeometryError("polygon has no area", 0)
 )
 
 func Point(g *geos.Geos, node osm.Node) (*geos.Geom, error) {
 	geom := g.Point(node.Long, node.Lat)
 	if geom == nil {
-		return nil, newGeometryError("couldn't create point", 1)
+		err := newGeometryError("couldn't create point", 1)
+		BuildStats.count(err)
+		return nil, err
 	}
+	BuildStats.count(nil)
 	g.DestroyLater(geom)
 	return geom, nil
 }
@@ -79,20 +83,59 @@ func unduplicateNodes(nodes []osm.Node) []osm.Node {
 	return result
 }
 
+// NodesBounds returns the bounds of all nodes, or geos.NilBounds
+// if nodes is empty.
+func NodesBounds(nodes []osm.Node) geos.Bounds {
+	bounds := geos.NilBounds
+	for _, nd := range nodes {
+		bounds = bounds.Extend(geos.Bounds{MinX: nd.Long, MinY: nd.Lat, MaxX: nd.Long, MaxY: nd.Lat})
+	}
+	return bounds
+}
+
+// NodesToCoords returns the coordinates of nodes as interleaved
+// long/lat values, as expected by CreateCoordSeqFromBuffer.
+func NodesToCoords(nodes []osm.Node) []float64 {
+	coords := make([]float64, 0, len(nodes)*2)
+	for _, nd := range nodes {
+		coords = append(coords, nd.Long, nd.Lat)
+	}
+	return coords
+}
+
+// SnapNodes rounds the coordinates of all nodes to a grid of gridSize
+// (e.g. 0.01 for two decimal places). Shared boundaries of adjacent features
+// get identical coordinates, even if their nodes differ by coordinate noise.
+// nodes are modified in place.
+func SnapNodes(nodes []osm.Node, gridSize float64) {
+	if gridSize <= 0 {
+		return
+	}
+	for i := range nodes {
+		nodes[i].Long = math.Round(nodes[i].Long/gridSize) * gridSize
+		nodes[i].Lat = math.Round(nodes[i].Lat/gridSize) * gridSize
+	}
+}
+
 func LineString(g *geos.Geos, nodes []osm.Node) (*geos.Geom, error) {
+	geom, err := lineString(g, nodes)
+	BuildStats.count(err)
+	return geom, err
+}
+
+// lineString builds the LineString without updating BuildStats, e.g. for
+// parts of relations.
+func lineString(g *geos.Geos, nodes []osm.Node) (*geos.Geom, error) {
 	nodes = unduplicateNodes(nodes)
 	if len(nodes) < 2 {
 		return nil, ErrorOneNodeWay
 	}
 
-	coordSeq, err := g.CreateCoordSeq(uint32(len(nodes)), 2)
+	coordSeq, err := g.CreateCoordSeqFromBuffer(NodesToCoords(nodes))
 	if err != nil {
 		return nil, err
 	}
 	// coordSeq inherited by LineString
-	for i, nd := range nodes {
-		coordSeq.SetXY(g, uint32(i), nd.Long, nd.Lat)
-	}
 	geom, err := coordSeq.AsLineString(g)
 	if err != nil {
 		// coordSeq gets Destroy by GEOS
@@ -102,24 +145,50 @@ func LineString(g *geos.Geos, nodes []osm.Node) (*geos.Geom, error) {
 	return geom, nil
 }
 
+// Polygon builds a polygon from the nodes of a closed way. Polygons with
+// a self-touching ring (e.g. figure-eight rings) are split into a
+// MultiPolygon of the loops, see splitSelfTouch.
 func Polygon(g *geos.Geos, nodes []osm.Node) (*geos.Geom, error) {
+	geom, err := polygon(g, nodes)
+	if err == nil {
+		geom = splitSelfTouch(g, geom)
+	}
+	BuildStats.count(err)
+	return geom, err
+}
+
+// splitSelfTouch returns the (Multi)Polygon of all loops of the exterior
+// ring of geom, if the ring touches itself (see geos.HasSelfTouch and
+// PolygonizeRing). These polygons are invalid, but the polygonized loops
+// are valid. Returns geom for all other polygons and if the ring has no
+// loops with an area (e.g. for collapsed rings).
+func splitSelfTouch(g *geos.Geos, geom *geos.Geom) *geos.Geom {
+	ring := g.ExteriorRing(geom)
+	if ring == nil || !g.HasSelfTouch(ring) {
+		return geom
+	}
+	split := g.PolygonizeRing(ring)
+	if split == nil {
+		return geom
+	}
+	g.DestroyLater(split)
+	return split
+}
+
+// polygon builds the Polygon without updating BuildStats, e.g. for rings
+// of relations.
+func polygon(g *geos.Geos, nodes []osm.Node) (*geos.Geom, error) {
 	nodes = unduplicateNodes(nodes)
 	if len(nodes) < 4 {
 		return nil, ErrorNoRing
 	}
 
-	coordSeq, err := g.CreateCoordSeq(uint32(len(nodes)), 2)
+	coordSeq, err := g.CreateCoordSeqFromBuffer(NodesToCoords(nodes))
 	if err != nil {
 		return nil, err
 	}
 
 	// coordSeq inherited by LinearRing, no destroy
-	for i, nd := range nodes {
-		err := coordSeq.SetXY(g, uint32(i), nd.Long, nd.Lat)
-		if err != nil {
-			return nil, err
-		}
-	}
 	ring, err := coordSeq.AsLinearRing(g)
 	if err != nil {
 		// coordSeq gets Destroy by GEOS
@@ -136,6 +205,70 @@ func Polygon(g *geos.Geos, nodes []osm.Node) (*geos.Geom, error) {
 	return geom, nil
 }
 
+// CloseRing returns nodes with the last node snapped onto the first node,
+// if both are not identical but closer than maxRingGap (e.g. from coordinate
+// noise). Returns nodes unchanged otherwise. nodes is not modified.
+func CloseRing(nodes []osm.Node, maxRingGap float64) []osm.Node {
+	if len(nodes) < 4 {
+		return nodes
+	}
+	start, end := nodes[0], nodes[len(nodes)-1]
+	if nodesEqual(start, end) {
+		return nodes
+	}
+	if math.Hypot(start.Lat-end.Lat, start.Long-end.Long) >= maxRingGap {
+		return nodes
+	}
+	closed := make([]osm.Node, len(nodes))
+	copy(closed, nodes)
+	closed[len(closed)-1].Long = start.Long
+	closed[len(closed)-1].Lat = start.Lat
+	return closed
+}
+
+// PolygonWithRingGap is like Polygon, but it closes nearly closed rings
+// with CloseRing first.
+func PolygonWithRingGap(g *geos.Geos, nodes []osm.Node, maxRingGap float64) (*geos.Geom, error) {
+	return Polygon(g, CloseRing(nodes, maxRingGap))
+}
+
+// PolygonFromWay builds a polygon from the nodes of a closed way. Unlike
+// Polygon, the ring is checked by the node IDs: returns ErrorNoRing if the
+// first and last node are not the same node. The last coordinate is set to
+// the first coordinate, in case both differ (e.g. after a separate
+// reprojection). nodes is not modified.
+func PolygonFromWay(g *geos.Geos, nodes []osm.Node) (*geos.Geom, error) {
+	if len(nodes) < 4 {
+		return nil, ErrorNoRing
+	}
+	first, last := nodes[0], nodes[len(nodes)-1]
+	if first.ID != last.ID {
+		return nil, ErrorNoRing
+	}
+	if !nodesEqual(first, last) {
+		closed := make([]osm.Node, len(nodes))
+		copy(closed, nodes)
+		closed[len(closed)-1].Long = first.Long
+		closed[len(closed)-1].Lat = first.Lat
+		nodes = closed
+	}
+	return Polygon(g, nodes)
+}
+
+// NonZeroPolygon is like Polygon, but it returns ErrorZeroArea for
+// collapsed polygons (e.g. all nodes are collinear). These polygons can
+// be valid for some GEOS versions, but they are rejected by PostGIS.
+func NonZeroPolygon(g *geos.Geos, nodes []osm.Node) (*geos.Geom, error) {
+	geom, err := Polygon(g, nodes)
+	if err != nil {
+		return nil, err
+	}
+	if g.IsZeroArea(geom) {
+		return nil, ErrorZeroArea
+	}
+	return geom, nil
+}
+
 func AsGeomElement(g *geos.Geos, geom *geos.Geom) (Geometry, error) {
 	wkb := g.AsEwkbHex(geom)
 	if wkb == nil {
@@ -147,3 +280,62 @@ func AsGeomElement(g *geos.Geos, geom *geos.Geom) (Geometry, error) {
 		Geom: geom,
 	}, nil
 }
+
+// LineStringsWkb returns a Geometry with WKB for each LineString of the
+// MultiLineString multi (e.g. the result of a line merge). The parts are
+// cloned, so they stay valid after multi is destroyed. All errors are
+// GeometryErrors.
+func LineStringsWkb(g *geos.Geos, multi *geos.Geom) ([]*Geometry, error) {
+	parts := g.Geoms(multi)
+	if parts == nil {
+		return nil, newGeometryError("unable to get geometries of multilinestring", 1)
+	}
+	result := make([]*Geometry, 0, len(parts))
+	for _, part := range parts {
+		if g.TypeID(part) != geos.LineStringTypeID {
+			return nil, newGeometryError("expected linestring, got "+g.Type(part), 1)
+		}
+		line := g.Clone(part)
+		if line == nil {
+			return nil, newGeometryError("unable to clone linestring", 1)
+		}
+		g.DestroyLater(line)
+		wkb := g.AsEwkbHex(line)
+		if wkb == nil {
+			return nil, newGeometryError("could not create wkb", 1)
+		}
+		result = append(result, &Geometry{Wkb: wkb, Geom: line})
+	}
+	return result, nil
+}
+
+// LazyGeomElement returns a Geometry without creating the WKB. The WKB
+// is created by the first EwkbHex call, so no WKB is created for
+// geometries that get rejected (e.g. by a filter) before they are inserted.
+// geom is bound to g (see geos.Bind), so EwkbHex needs to be called from
+// the goroutine of g and before g.Finish.
+func LazyGeomElement(g *geos.Geos, geom *geos.Geom) Geometry {
+	g.Bind(geom)
+	return Geometry{Geom: geom}
+}
+
+// EwkbHex returns the hex encoded EWKB of the geometry. The WKB is created
+// on the first call for geometries from LazyGeomElement. Returns nil if the
+// WKB could not be created.
+func (geom *Geometry) EwkbHex() []byte {
+	if geom.Wkb == nil && geom.Geom != nil {
+		if g := geom.Geom.Handle(); g != nil {
+			geom.Wkb = g.AsEwkbHex(geom.Geom)
+		}
+	}
+	return geom.Wkb
+}
+
+// Geos returns the handle of geometries from LazyGeomElement and nil for
+// all other geometries. The same restrictions as for EwkbHex apply.
+func (geom *Geometry) Geos() *geos.Geos {
+	if geom.Geom == nil {
+		return nil
+	}
+	return geom.Geom.Handle()
+}
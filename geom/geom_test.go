@@ -1,6 +1,8 @@
 package geom
 
 import (
+	"bytes"
+	"reflect"
 	"testing"
 
 	osm "github.com/omniscale/go-osm"
@@ -42,6 +44,33 @@ func TestPolygon(t *testing.T) {
 	}
 }
 
+func TestPolygonSelfTouch(t *testing.T) {
+	// figure-eight, touching itself at 10 10
+	nodes := []osm.Node{
+		osm.Node{Long: 0, Lat: 0},
+		osm.Node{Long: 10, Lat: 0},
+		osm.Node{Long: 10, Lat: 10},
+		osm.Node{Long: 20, Lat: 10},
+		osm.Node{Long: 20, Lat: 20},
+		osm.Node{Long: 10, Lat: 20},
+		osm.Node{Long: 10, Lat: 10},
+		osm.Node{Long: 0, Lat: 10},
+		osm.Node{Long: 0, Lat: 0},
+	}
+	g := geos.NewGeos()
+	defer g.Finish()
+	geom, err := Polygon(g, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.TypeID(geom) != geos.MultiPolygonTypeID || g.NumGeoms(geom) != 2 {
+		t.Fatal("unexpected geometry", g.AsWkt(geom))
+	}
+	if !g.IsValid(geom) || geom.Area() != 200 {
+		t.Error("unexpected geometry", g.AsWkt(geom))
+	}
+}
+
 func TestPolygonNotClosed(t *testing.T) {
 	nodes := []osm.Node{
 		osm.Node{Lat: 0, Long: 0},
@@ -56,6 +85,112 @@ func TestPolygonNotClosed(t *testing.T) {
 	}
 }
 
+func TestPolygonWithRingGap(t *testing.T) {
+	nodes := []osm.Node{
+		osm.Node{Lat: 0, Long: 0},
+		osm.Node{Lat: 0, Long: 10},
+		osm.Node{Lat: 10, Long: 10},
+		osm.Node{Lat: 10, Long: 0},
+		osm.Node{Lat: 1e-7, Long: 1e-7},
+	}
+	g := geos.NewGeos()
+	defer g.Finish()
+	if _, err := Polygon(g, nodes); err == nil {
+		t.Fatal("no error for unclosed ring")
+	}
+	if _, err := PolygonWithRingGap(g, nodes, 1e-8); err == nil {
+		t.Fatal("no error for gap larger than maxRingGap")
+	}
+
+	geom, err := PolygonWithRingGap(g, nodes, 1e-6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.IsValid(geom) || geom.Area() != 100.0 {
+		t.Fatal(g.AsWkt(geom))
+	}
+	if nodes[4].Lat != 1e-7 {
+		t.Error("nodes modified")
+	}
+}
+
+func TestSnapNodes(t *testing.T) {
+	a := []osm.Node{
+		osm.Node{Lat: 0, Long: 0},
+		osm.Node{Lat: 0, Long: 10.001},
+		osm.Node{Lat: 10.0004, Long: 9.998},
+		osm.Node{Lat: 10, Long: 0},
+		osm.Node{Lat: 0, Long: 0},
+	}
+	b := []osm.Node{
+		osm.Node{Lat: 0.0003, Long: 10.0008},
+		osm.Node{Lat: 0, Long: 20},
+		osm.Node{Lat: 10, Long: 20},
+		osm.Node{Lat: 9.9996, Long: 10.0001},
+		osm.Node{Lat: 0.0003, Long: 10.0008},
+	}
+	SnapNodes(a, 0.01)
+	SnapNodes(b, 0.01)
+
+	if !reflect.DeepEqual(NodesToCoords(a[1:3]), NodesToCoords([]osm.Node{b[0], b[3]})) {
+		t.Fatal("shared edge not identical", a[1:3], b[0], b[3])
+	}
+
+	g := geos.NewGeos()
+	defer g.Finish()
+	pa, err := Polygon(g, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb, err := Polygon(g, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shared := g.Intersection(pa, pb); !g.Equals(shared, g.FromWkt("LINESTRING(10 0, 10 10)")) {
+		t.Error("unexpected shared boundary", g.AsWkt(shared))
+	}
+}
+
+func TestPolygonFromWay(t *testing.T) {
+	node := func(id int64, long, lat float64) osm.Node {
+		nd := osm.Node{Long: long, Lat: lat}
+		nd.ID = id
+		return nd
+	}
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	open := []osm.Node{node(1, 0, 0), node(2, 10, 0), node(3, 10, 10), node(4, 0, 10), node(5, 0, 0)}
+	if _, err := PolygonFromWay(g, open); err != ErrorNoRing {
+		t.Error("open way not rejected", err)
+	}
+	if _, err := PolygonFromWay(g, open[:3]); err != ErrorNoRing {
+		t.Error("short way not rejected", err)
+	}
+
+	closed := []osm.Node{node(1, 0, 0), node(2, 10, 0), node(3, 10, 10), node(4, 0, 10), node(1, 0, 0)}
+	geom, err := PolygonFromWay(g, closed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.IsValid(geom) || geom.Area() != 100 {
+		t.Error("unexpected polygon", g.AsWkt(geom))
+	}
+
+	// same node, but different coordinates
+	closed[4].Long = 1e-6
+	geom, err = PolygonFromWay(g, closed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.IsValid(geom) || geom.Area() != 100 {
+		t.Error("unexpected polygon", g.AsWkt(geom))
+	}
+	if closed[4].Long != 1e-6 {
+		t.Error("nodes modified")
+	}
+}
+
 func TestPolygonIntersection(t *testing.T) {
 	nodes := []osm.Node{
 		osm.Node{Lat: 0, Long: 0},
@@ -109,6 +244,38 @@ func BenchmarkLineStringNoGeos(b *testing.B) {
 	}
 }
 
+// benchmarkLineStringRejected builds linestrings that are rejected by
+// a length filter, with or without creating the WKB first.
+func benchmarkLineStringRejected(b *testing.B, lazy bool) {
+	size := 16
+	nodes := make([]osm.Node, size)
+	for i := 0; i < size; i++ {
+		nodes[i] = osm.Node{Lat: 0, Long: float64(i)}
+	}
+	g := geos.NewGeos()
+	g.SetHandleSrid(4326)
+	defer g.Finish()
+
+	for i := 0; i < b.N; i++ {
+		geosgeom, err := LineString(g, nodes)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var geom Geometry
+		if lazy {
+			geom = LazyGeomElement(g, geosgeom)
+		} else if geom, err = AsGeomElement(g, geosgeom); err != nil {
+			b.Fatal(err)
+		}
+		if geom.Geom.Length() > 100 {
+			geom.EwkbHex()
+		}
+	}
+}
+
+func BenchmarkLineStringRejected(b *testing.B)     { benchmarkLineStringRejected(b, false) }
+func BenchmarkLineStringRejectedLazy(b *testing.B) { benchmarkLineStringRejected(b, true) }
+
 func BenchmarkPolygon(b *testing.B) {
 	size := 16
 	nodes := make([]osm.Node, size)
@@ -144,6 +311,19 @@ func BenchmarkPolygonNoGeos(b *testing.B) {
 	}
 }
 
+func TestNodesToCoords(t *testing.T) {
+	nodes := []osm.Node{
+		osm.Node{Lat: 47.0, Long: 8.0},
+		osm.Node{Lat: 48.0, Long: 9.0},
+		osm.Node{Lat: 49.0, Long: 10.0},
+	}
+	coords := NodesToCoords(nodes)
+	expected := []float64{8, 47, 9, 48, 10, 49}
+	if !reflect.DeepEqual(coords, expected) {
+		t.Fatal(coords)
+	}
+}
+
 func TestUnduplicateNodes(t *testing.T) {
 	var nodes []osm.Node
 
@@ -197,3 +377,136 @@ func TestUnduplicateNodes(t *testing.T) {
 	}
 
 }
+
+func TestLazyGeomElement(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	geom, err := Point(g, osm.Node{Long: 8, Lat: 53})
+	if err != nil {
+		t.Fatal(err)
+	}
+	geometry := LazyGeomElement(g, geom)
+
+	// rejected geometries never need WKB
+	if geometry.Wkb != nil {
+		t.Fatal("WKB created before first access")
+	}
+	if geometry.Geos() != g {
+		t.Error("geometry not bound to handle")
+	}
+
+	wkb := geometry.EwkbHex()
+	if wkb == nil || string(wkb) != string(g.AsEwkbHex(geom)) {
+		t.Fatal("unexpected WKB", string(wkb))
+	}
+	if string(geometry.Wkb) != string(wkb) {
+		t.Error("WKB not cached")
+	}
+
+	// geometries without handle and WKB
+	unbound := Geometry{Geom: g.Point(8, 53)}
+	if unbound.EwkbHex() != nil || unbound.Geos() != nil {
+		t.Error("unexpected WKB or handle for unbound geometry")
+	}
+}
+
+func TestBuildWorkers(t *testing.T) {
+	jobs := make(chan BuildJob)
+	results := BuildWorkers(4, 3857, 0, jobs)
+
+	const numJobs = 200
+	go func() {
+		for i := 0; i < numJobs; i++ {
+			jobs <- BuildJob{Way: &osm.Way{
+				Element: osm.Element{ID: int64(i)},
+				Nodes: []osm.Node{
+					osm.Node{Lat: 0, Long: 0},
+					osm.Node{Lat: 0, Long: float64(i + 1)},
+				},
+			}}
+		}
+		// invalid way with a single node
+		jobs <- BuildJob{Way: &osm.Way{Element: osm.Element{ID: -1}, Nodes: []osm.Node{osm.Node{}}}}
+		close(jobs)
+	}()
+
+	built := 0
+	failed := 0
+	for r := range results {
+		if r.Err != nil {
+			if r.Job.Way.ID != -1 {
+				t.Error("unexpected error", r.Err)
+			}
+			failed += 1
+			continue
+		}
+		if len(r.Geometry.Wkb) == 0 {
+			t.Error("missing WKB for", r.Job.Way.ID)
+		}
+		if l := r.Geometry.Geom.Length(); l != float64(r.Job.Way.ID+1) {
+			t.Error("unexpected length", r.Job.Way.ID, l)
+		}
+		built += 1
+	}
+	if built != numJobs || failed != 1 {
+		t.Error("unexpected results", built, failed)
+	}
+}
+
+func TestNonZeroPolygon(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	collinear := []osm.Node{
+		osm.Node{Lat: 0, Long: 0},
+		osm.Node{Lat: 5, Long: 5},
+		osm.Node{Lat: 10, Long: 10},
+		osm.Node{Lat: 0, Long: 0},
+	}
+	if _, err := NonZeroPolygon(g, collinear); err != ErrorZeroArea {
+		t.Error("expected ErrorZeroArea, got", err)
+	}
+
+	square := []osm.Node{
+		osm.Node{Lat: 0, Long: 0},
+		osm.Node{Lat: 0, Long: 10},
+		osm.Node{Lat: 10, Long: 10},
+		osm.Node{Lat: 10, Long: 0},
+		osm.Node{Lat: 0, Long: 0},
+	}
+	geom, err := NonZeroPolygon(g, square)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if geom.Area() != 100 {
+		t.Error("unexpected area", geom.Area())
+	}
+}
+
+func TestLineStringsWkb(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	multi := g.FromWkt("MULTILINESTRING((0 0, 10 0), (20 0, 30 0, 30 10))")
+	geoms, err := LineStringsWkb(g, multi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(geoms) != 2 {
+		t.Fatal("unexpected geometries", geoms)
+	}
+	for i, expected := range []string{"LINESTRING(0 0, 10 0)", "LINESTRING(20 0, 30 0, 30 10)"} {
+		if !g.Equals(geoms[i].Geom, g.FromWkt(expected)) {
+			t.Error("unexpected geometry", g.AsWkt(geoms[i].Geom))
+		}
+		if wkb := g.AsEwkbHex(geoms[i].Geom); !bytes.Equal(wkb, geoms[i].Wkb) {
+			t.Error("unexpected wkb", string(geoms[i].Wkb))
+		}
+	}
+
+	_, err = LineStringsWkb(g, g.FromWkt("GEOMETRYCOLLECTION(POINT(0 0))"))
+	if _, ok := err.(*GeometryError); !ok {
+		t.Error("no GeometryError for point", err)
+	}
+}
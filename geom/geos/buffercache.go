@@ -0,0 +1,103 @@
+package geos
+
+import "container/list"
+
+// EnableBufferCache caches the results of the last size Buffer calls, e.g.
+// for geometries that are buffered with the same distance for multiple
+// zoom levels. The cache is keyed by the GeomHash of the geometry and the
+// buffer size, and Buffer returns a clone of the cached result if the
+// geometry is also exactly equal to the cached source geometry. The least
+// recently used results are destroyed if the cache is full. A size of 0
+// disables the cache.
+func (g *Geos) EnableBufferCache(size int) {
+	if g.bufferCache != nil {
+		g.bufferCache.clear(g)
+		g.bufferCache = nil
+	}
+	if size > 0 {
+		g.bufferCache = &bufferCache{
+			size:    size,
+			entries: make(map[bufferKey]*list.Element, size),
+			lru:     list.New(),
+		}
+	}
+}
+
+type bufferKey struct {
+	hash uint64
+	size float64
+}
+
+type bufferEntry struct {
+	key bufferKey
+	// source is a clone of the buffered geometry, to detect hash collisions
+	source *Geom
+	geom   *Geom
+}
+
+type bufferCache struct {
+	size    int
+	entries map[bufferKey]*list.Element
+	// lru contains all entries, most recently used first
+	lru *list.List
+	// hits and misses are for tests
+	hits   int
+	misses int
+}
+
+func (c *bufferCache) buffer(g *Geos, geom *Geom, size float64) *Geom {
+	hash := g.GeomHash(geom)
+	if hash == 0 {
+		return g.buffer(geom, size)
+	}
+	key := bufferKey{hash: hash, size: size}
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*bufferEntry)
+		if g.EqualsExact(entry.source, geom, 0) {
+			c.hits++
+			c.lru.MoveToFront(elem)
+			return g.Clone(entry.geom)
+		}
+		// hash collision, replace entry
+		c.remove(g, elem)
+	}
+
+	c.misses++
+	buffered := g.buffer(geom, size)
+	if buffered == nil {
+		return nil
+	}
+	source := g.Clone(geom)
+	if source == nil {
+		return buffered
+	}
+	cached := g.Clone(buffered)
+	if cached == nil {
+		g.Destroy(source)
+		return buffered
+	}
+	c.entries[key] = c.lru.PushFront(&bufferEntry{key: key, source: source, geom: cached})
+	for c.lru.Len() > c.size {
+		c.remove(g, c.lru.Back())
+	}
+	return buffered
+}
+
+// remove removes elem from the cache and destroys its geometries.
+func (c *bufferCache) remove(g *Geos, elem *list.Element) {
+	entry := c.lru.Remove(elem).(*bufferEntry)
+	delete(c.entries, entry.key)
+	g.Destroy(entry.source)
+	g.Destroy(entry.geom)
+}
+
+// clear destroys all cached geometries.
+func (c *bufferCache) clear(g *Geos) {
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*bufferEntry)
+		g.Destroy(entry.source)
+		g.Destroy(entry.geom)
+	}
+	c.entries = make(map[bufferKey]*list.Element, c.size)
+	c.lru.Init()
+}
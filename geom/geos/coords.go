@@ -4,19 +4,72 @@ package geos
 #cgo LDFLAGS: -lgeos_c
 #include "geos_c.h"
 #include <stdlib.h>
+
+#define GEOS_AT_LEAST(major, minor) (GEOS_VERSION_MAJOR > major || (GEOS_VERSION_MAJOR == major && GEOS_VERSION_MINOR >= minor))
+
+static GEOSCoordSequence *coordSeqFromBuffer(GEOSContextHandle_t handle, const double *buf, unsigned int size) {
+#if GEOS_AT_LEAST(3, 10)
+	return GEOSCoordSeq_copyFromBuffer_r(handle, buf, size, 0, 0);
+#else
+	return NULL;
+#endif
+}
 */
 import "C"
 
+import (
+	"fmt"
+	"sync/atomic"
+)
+
 type CoordSeq struct {
 	v *C.GEOSCoordSequence
 }
 
+func newCoordSeq(v *C.GEOSCoordSequence) *CoordSeq {
+	atomic.AddInt64(&liveGeoms, 1)
+	return &CoordSeq{v}
+}
+
+// CreateCoordSeq creates a new CoordSeq with size coordinates. dim needs
+// to be 2 or 3. size can be 0 for empty geometries.
 func (g *Geos) CreateCoordSeq(size, dim uint32) (*CoordSeq, error) {
+	if dim != 2 && dim != 3 {
+		return nil, CreateError(fmt.Sprintf("could not create CoordSeq with dimension %d (needs to be 2 or 3)", dim))
+	}
 	result := C.GEOSCoordSeq_create_r(g.v, C.uint(size), C.uint(dim))
 	if result == nil {
 		return nil, CreateError("could not create CoordSeq")
 	}
-	return &CoordSeq{result}, nil
+	return newCoordSeq(result), nil
+}
+
+// CreateCoordSeqFromBuffer creates a new 2D CoordSeq from interleaved
+// x/y values. The values are copied in one call with GEOS 3.10 or newer
+// and set coordinate by coordinate otherwise.
+func (g *Geos) CreateCoordSeqFromBuffer(coords []float64) (*CoordSeq, error) {
+	if len(coords)%2 != 0 {
+		return nil, CreateError(fmt.Sprintf("could not create CoordSeq from odd number of values (%d)", len(coords)))
+	}
+	size := uint32(len(coords) / 2)
+	if !caps.CoordSeqBuffer || size == 0 {
+		coordSeq, err := g.CreateCoordSeq(size, 2)
+		if err != nil {
+			return nil, err
+		}
+		for i := uint32(0); i < size; i++ {
+			if err := coordSeq.SetXY(g, i, coords[2*i], coords[2*i+1]); err != nil {
+				g.DestroyCoordSeq(coordSeq)
+				return nil, err
+			}
+		}
+		return coordSeq, nil
+	}
+	result := C.coordSeqFromBuffer(g.v, (*C.double)(&coords[0]), C.uint(size))
+	if result == nil {
+		return nil, CreateError("could not create CoordSeq")
+	}
+	return newCoordSeq(result), nil
 }
 
 func (g *CoordSeq) SetXY(handle *Geos, i uint32, x, y float64) error {
@@ -31,33 +84,147 @@ func (g *CoordSeq) SetXY(handle *Geos, i uint32, x, y float64) error {
 
 func (g *CoordSeq) AsPoint(handle *Geos) (*Geom, error) {
 	geom := C.GEOSGeom_createPoint_r(handle.v, g.v)
+	// coordinates are owned by the geometry or destroyed by GEOS
+	released(1)
 	if geom == nil {
 		return nil, CreateError("unable to create Point")
 	}
-	return &Geom{geom}, nil
+	return newGeom(geom), nil
 }
 
 func (g *CoordSeq) AsLineString(handle *Geos) (*Geom, error) {
 	geom := C.GEOSGeom_createLineString_r(handle.v, g.v)
+	// coordinates are owned by the geometry or destroyed by GEOS
+	released(1)
 	if geom == nil {
 		return nil, CreateError("unable to create LineString")
 	}
-	return &Geom{geom}, nil
+	return newGeom(geom), nil
 }
 
 func (g *CoordSeq) AsLinearRing(handle *Geos) (*Geom, error) {
 	ring := C.GEOSGeom_createLinearRing_r(handle.v, g.v)
+	// coordinates are owned by the geometry or destroyed by GEOS
+	released(1)
 	if ring == nil {
 		return nil, CreateError("unable to create LinearRing")
 	}
-	return &Geom{ring}, nil
+	return newGeom(ring), nil
+}
+
+// LinearRing creates a new LinearRing from coords. Open rings are closed
+// by appending the first coordinate. Returns a CreateError if the closed
+// ring has less than four coordinates.
+func (g *Geos) LinearRing(coords [][2]float64) (*Geom, error) {
+	if len(coords) > 0 && coords[0] != coords[len(coords)-1] {
+		coords = append(coords[:len(coords):len(coords)], coords[0])
+	}
+	if len(coords) < 4 {
+		return nil, CreateError(fmt.Sprintf("could not create LinearRing with %d coordinates (needs at least 4 for a closed ring)", len(coords)))
+	}
+	buf := make([]float64, 0, len(coords)*2)
+	for _, c := range coords {
+		buf = append(buf, c[0], c[1])
+	}
+	coordSeq, err := g.CreateCoordSeqFromBuffer(buf)
+	if err != nil {
+		return nil, err
+	}
+	// coordSeq inherited by LinearRing
+	return coordSeq.AsLinearRing(g)
+}
+
+// ConcatLines creates a new LineString from the coordinates of all
+// LineStrings in lines, in the order of lines. The start coordinate of a
+// line is skipped if it is identical to the end coordinate of the previous
+// line. Lines that do not touch are connected with a straight segment.
+func (g *Geos) ConcatLines(lines []*Geom) (*Geom, error) {
+	var buf []float64
+	for i, line := range lines {
+		if typeID := g.TypeID(line); typeID != LineStringTypeID {
+			return nil, CreateError(fmt.Sprintf("could not concat line %d of type %s", i, g.Type(line)))
+		}
+		coords, err := g.Coords(line)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(buf); n > 0 && len(coords) > 0 && buf[n-2] == coords[0][0] && buf[n-1] == coords[0][1] {
+			coords = coords[1:]
+		}
+		for _, c := range coords {
+			buf = append(buf, c[0], c[1])
+		}
+	}
+	if len(buf) < 4 {
+		return nil, CreateError("could not create LineString with less than two coordinates")
+	}
+	coordSeq, err := g.CreateCoordSeqFromBuffer(buf)
+	if err != nil {
+		return nil, err
+	}
+	// coordSeq inherited by LineString
+	return coordSeq.AsLineString(g)
 }
 
 func (g *Geos) DestroyCoordSeq(coordSeq *CoordSeq) {
 	if coordSeq.v != nil {
 		C.GEOSCoordSeq_destroy_r(g.v, coordSeq.v)
 		coordSeq.v = nil
+		released(1)
 	} else {
 		panic("double free?")
 	}
 }
+
+// Coords returns the coordinates of a Point, LineString or LinearRing.
+func (g *Geos) Coords(geom *Geom) ([][2]float64, error) {
+	cs := C.GEOSGeom_getCoordSeq_r(g.v, geom.v)
+	if cs == nil {
+		return nil, Error("unable to get CoordSeq")
+	}
+	var size C.uint
+	if C.GEOSCoordSeq_getSize_r(g.v, cs, &size) == 0 {
+		return nil, Error("unable to get CoordSeq size")
+	}
+	coords := make([][2]float64, int(size))
+	var x, y C.double
+	for i := range coords {
+		if C.GEOSCoordSeq_getX_r(g.v, cs, C.uint(i), &x) == 0 {
+			return nil, Error("unable to GetX")
+		}
+		if C.GEOSCoordSeq_getY_r(g.v, cs, C.uint(i), &y) == 0 {
+			return nil, Error("unable to GetY")
+		}
+		coords[i] = [2]float64{float64(x), float64(y)}
+	}
+	return coords, nil
+}
+
+// PolygonCoords returns the coordinates of all rings of a Polygon or
+// MultiPolygon, grouped by polygon. The rings of each polygon start with
+// the exterior ring, followed by the interior rings.
+func (g *Geos) PolygonCoords(geom *Geom) ([][][][2]float64, error) {
+	switch g.TypeID(geom) {
+	case PolygonTypeID, MultiPolygonTypeID:
+	default:
+		return nil, Error("PolygonCoords requires a Polygon or MultiPolygon, got " + g.Type(geom))
+	}
+	var polygons [][][][2]float64
+	for _, polygon := range g.Geoms(geom) {
+		exterior := g.ExteriorRing(polygon)
+		if exterior == nil {
+			return nil, Error("unable to get exterior ring")
+		}
+		interiors := g.InteriorRings(polygon)
+		rings := make([][][2]float64, 0, 1+len(interiors))
+		for _, ring := range append([]*Geom{exterior}, interiors...) {
+			coords, err := g.Coords(ring)
+			if err != nil {
+				return nil, err
+			}
+			rings = append(rings, coords)
+		}
+		polygons = append(polygons, rings)
+	}
+	return polygons, nil
+}
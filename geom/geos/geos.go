@@ -14,7 +14,10 @@ import "C"
 
 import (
 	"errors"
+	"hash/fnv"
+	"math"
 	"runtime"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/omniscale/imposm3/log"
@@ -29,10 +32,16 @@ type Geos struct {
 	v         C.GEOSContextHandle_t
 	srid      int
 	wkbwriter *C.GEOSWKBWriter
+	// manualDestroy disables DestroyLater, see SetManualDestroy
+	manualDestroy bool
+	// bufferCache is nil if disabled, see EnableBufferCache
+	bufferCache *bufferCache
 }
 
 type Geom struct {
 	v *C.GEOSGeometry
+	// handle is set by Bind
+	handle *Geos
 }
 
 type CreateError string
@@ -53,6 +62,10 @@ func NewGeos() *Geos {
 }
 
 func (g *Geos) Finish() {
+	if g.bufferCache != nil {
+		g.bufferCache.clear(g)
+		g.bufferCache = nil
+	}
 	if g.v != nil {
 		C.finishGEOS_r(g.v)
 		g.v = nil
@@ -71,11 +84,35 @@ func init() {
 	C.initGEOS_debug()
 }
 
+// liveGeoms counts all Geoms and CoordSeqs that are owned by the caller
+// and not destroyed yet. Parts returned by Geoms or ExteriorRing are owned
+// by their parent geometry and they are not counted.
+var liveGeoms int64
+
+// LiveGeomCount returns the number of created Geoms and CoordSeqs that are
+// not yet destroyed, or passed to a constructor that takes ownership of them
+// (e.g. Polygon). A steadily growing count indicates a leak of geometries.
+func LiveGeomCount() int64 {
+	return atomic.LoadInt64(&liveGeoms)
+}
+
+func newGeom(v *C.GEOSGeometry) *Geom {
+	atomic.AddInt64(&liveGeoms, 1)
+	return &Geom{v: v}
+}
+
+// released updates the live count for n geometries or coordinate sequences
+// that are destroyed or owned by another geometry.
+func released(n int) {
+	atomic.AddInt64(&liveGeoms, -int64(n))
+}
+
 func (g *Geos) Destroy(geom *Geom) {
 	runtime.SetFinalizer(geom, nil)
 	if geom.v != nil {
 		C.GEOSGeom_destroy_r(g.v, geom.v)
 		geom.v = nil
+		released(1)
 	} else {
 		log.Printf("double free?")
 	}
@@ -83,12 +120,43 @@ func (g *Geos) Destroy(geom *Geom) {
 
 func destroyGeom(geom *Geom) {
 	C.GEOSGeom_destroy(geom.v)
+	released(1)
 }
 
+// DestroyLater registers a finalizer that destroys geom once it is no
+// longer referenced. It does nothing if SetManualDestroy is enabled.
 func (g *Geos) DestroyLater(geom *Geom) {
+	if g.manualDestroy {
+		return
+	}
 	runtime.SetFinalizer(geom, destroyGeom)
 }
 
+// SetManualDestroy disables the finalizers of DestroyLater for all
+// geometries created with this handle afterwards. This reduces the GC
+// overhead for many short-lived geometries.
+//
+// The caller is then responsible to call Destroy exactly once for each of
+// these geometries, including geometries returned by helpers that call
+// DestroyLater (e.g. geom.Polygon). Geometries that are not destroyed
+// leak, and geometries must not be used after Destroy.
+func (g *Geos) SetManualDestroy(manual bool) {
+	g.manualDestroy = manual
+}
+
+// Bind associates geom with the handle g, so that code without access to
+// the handle can use it for geom (see Handle), e.g. to create the WKB after
+// the geometry passed all filters. geom must only be used from the
+// goroutine of g and g must not be finished while geom is in use.
+func (g *Geos) Bind(geom *Geom) {
+	geom.handle = g
+}
+
+// Handle returns the handle of geom from Bind, or nil.
+func (geom *Geom) Handle() *Geos {
+	return geom.handle
+}
+
 func (g *Geos) Clone(geom *Geom) *Geom {
 	if geom == nil || geom.v == nil {
 		return nil
@@ -98,13 +166,23 @@ func (g *Geos) Clone(geom *Geom) *Geom {
 	if result == nil {
 		return nil
 	}
-	return &Geom{result}
+	return newGeom(result)
 }
 
 func (g *Geos) SetHandleSrid(srid int) {
 	g.srid = srid
 }
 
+// SRID returns the SRID of geom, or 0 if it is not set.
+func (g *Geos) SRID(geom *Geom) int {
+	return int(C.GEOSGetSRID_r(g.v, geom.v))
+}
+
+// SetSRID sets the SRID of geom.
+func (g *Geos) SetSRID(geom *Geom, srid int) {
+	C.GEOSSetSRID_r(g.v, geom.v, C.int(srid))
+}
+
 func (g *Geos) NumGeoms(geom *Geom) int32 {
 	count := int32(C.GEOSGetNumGeometries_r(g.v, geom.v))
 	return count
@@ -115,6 +193,12 @@ func (g *Geos) NumCoordinates(geom *Geom) int32 {
 	return count
 }
 
+// CoordDimension returns the number of coordinate dimensions
+// of geom (2 or 3), or 0 on errors.
+func (g *Geos) CoordDimension(geom *Geom) int {
+	return int(C.GEOSGeom_getCoordinateDimension_r(g.v, geom.v))
+}
+
 func (g *Geos) Geoms(geom *Geom) []*Geom {
 	count := g.NumGeoms(geom)
 	var result []*Geom
@@ -123,7 +207,7 @@ func (g *Geos) Geoms(geom *Geom) []*Geom {
 		if part == nil {
 			return nil
 		}
-		result = append(result, &Geom{part})
+		result = append(result, &Geom{v: part})
 	}
 	return result
 }
@@ -133,7 +217,25 @@ func (g *Geos) ExteriorRing(geom *Geom) *Geom {
 	if ring == nil {
 		return nil
 	}
-	return &Geom{ring}
+	return &Geom{v: ring}
+}
+
+// InteriorRings returns the interior rings of the polygon geom. The rings
+// are owned by geom and must not be destroyed. Returns nil on errors.
+func (g *Geos) InteriorRings(geom *Geom) []*Geom {
+	count := C.GEOSGetNumInteriorRings_r(g.v, geom.v)
+	if count < 0 {
+		return nil
+	}
+	rings := make([]*Geom, 0, int(count))
+	for i := 0; i < int(count); i++ {
+		ring := C.GEOSGetInteriorRingN_r(g.v, geom.v, C.int(i))
+		if ring == nil {
+			return nil
+		}
+		rings = append(rings, &Geom{v: ring})
+	}
+	return rings
 }
 
 func (g *Geos) BoundsPolygon(bounds Bounds) *Geom {
@@ -185,48 +287,60 @@ func (g *Geos) Point(x, y float64) *Geom {
 }
 
 func (g *Geos) Polygon(exterior *Geom, interiors []*Geom) *Geom {
-	if len(interiors) == 0 {
-		geom := C.GEOSGeom_createPolygon_r(g.v, exterior.v, nil, C.uint(0))
-		if geom == nil {
-			return nil
-		}
-		err := C.GEOSNormalize_r(g.v, geom)
-		if err != 0 {
-			C.GEOSGeom_destroy(geom)
-			return nil
-		}
-		return &Geom{geom}
+	geom := g.createPolygon(exterior, interiors)
+	if geom == nil {
+		return nil
 	}
+	if C.GEOSNormalize_r(g.v, geom.v) != 0 {
+		g.Destroy(geom)
+		return nil
+	}
+	return geom
+}
 
-	interiorPtr := make([]*C.GEOSGeometry, len(interiors))
-	for i, geom := range interiors {
-		interiorPtr[i] = geom.v
+// createPolygon creates a Polygon like Polygon, but without normalizing
+// the orientation and start vertex of the rings.
+func (g *Geos) createPolygon(exterior *Geom, interiors []*Geom) *Geom {
+	var interiorPtr **C.GEOSGeometry
+	if len(interiors) > 0 {
+		ptrs := make([]*C.GEOSGeometry, len(interiors))
+		for i, geom := range interiors {
+			ptrs[i] = geom.v
+		}
+		interiorPtr = &ptrs[0]
 	}
-	geom := C.GEOSGeom_createPolygon_r(g.v, exterior.v, &interiorPtr[0], C.uint(len(interiors)))
+	geom := C.GEOSGeom_createPolygon_r(g.v, exterior.v, interiorPtr, C.uint(len(interiors)))
 	if geom == nil {
 		return nil
 	}
-	err := C.GEOSNormalize_r(g.v, geom)
-	if err != 0 {
-		C.GEOSGeom_destroy(geom)
-		return nil
-	}
-	return &Geom{geom}
+	released(1 + len(interiors))
+	return newGeom(geom)
 }
 
+// MultiPolygon creates a MultiPolygon from polygons. The polygons are
+// inherited by the MultiPolygon. Returns nil if one of the geometries is not
+// a Polygon, all polygons are destroyed in this case.
 func (g *Geos) MultiPolygon(polygons []*Geom) *Geom {
 	if len(polygons) == 0 {
 		return nil
 	}
 	polygonPtr := make([]*C.GEOSGeometry, len(polygons))
 	for i, geom := range polygons {
+		if typeID := g.TypeID(geom); typeID != PolygonTypeID {
+			log.Printf("unable to create MultiPolygon, geometry %d is a %s", i, g.Type(geom))
+			for _, p := range polygons {
+				g.Destroy(p)
+			}
+			return nil
+		}
 		polygonPtr[i] = geom.v
 	}
 	geom := C.GEOSGeom_createCollection_r(g.v, C.GEOS_MULTIPOLYGON, &polygonPtr[0], C.uint(len(polygons)))
 	if geom == nil {
 		return nil
 	}
-	return &Geom{geom}
+	released(len(polygons))
+	return newGeom(geom)
 }
 func (g *Geos) MultiLineString(lines []*Geom) *Geom {
 	if len(lines) == 0 {
@@ -240,7 +354,128 @@ func (g *Geos) MultiLineString(lines []*Geom) *Geom {
 	if geom == nil {
 		return nil
 	}
-	return &Geom{geom}
+	released(len(lines))
+	return newGeom(geom)
+}
+
+// MultiPoint creates a MultiPoint from points. The points are inherited
+// by the MultiPoint. Returns nil if one of the geometries is not a Point,
+// all points are destroyed in this case.
+func (g *Geos) MultiPoint(points []*Geom) *Geom {
+	if len(points) == 0 {
+		return nil
+	}
+	pointPtr := make([]*C.GEOSGeometry, len(points))
+	for i, geom := range points {
+		if typeID := g.TypeID(geom); typeID != PointTypeID {
+			log.Printf("unable to create MultiPoint, geometry %d is a %s", i, g.Type(geom))
+			for _, p := range points {
+				g.Destroy(p)
+			}
+			return nil
+		}
+		pointPtr[i] = geom.v
+	}
+	geom := C.GEOSGeom_createCollection_r(g.v, C.GEOS_MULTIPOINT, &pointPtr[0], C.uint(len(points)))
+	if geom == nil {
+		return nil
+	}
+	released(len(points))
+	return newGeom(geom)
+}
+
+// GeometryCollection creates a GeometryCollection from geoms of any type.
+// Returns an empty GeometryCollection if geoms is empty.
+func (g *Geos) GeometryCollection(geoms []*Geom) *Geom {
+	if len(geoms) == 0 {
+		geom := C.GEOSGeom_createEmptyCollection_r(g.v, C.GEOS_GEOMETRYCOLLECTION)
+		if geom == nil {
+			return nil
+		}
+		return newGeom(geom)
+	}
+	geomPtr := make([]*C.GEOSGeometry, len(geoms))
+	for i, geom := range geoms {
+		geomPtr[i] = geom.v
+	}
+	geom := C.GEOSGeom_createCollection_r(g.v, C.GEOS_GEOMETRYCOLLECTION, &geomPtr[0], C.uint(len(geoms)))
+	if geom == nil {
+		return nil
+	}
+	released(len(geoms))
+	return newGeom(geom)
+}
+
+// ToMulti wraps a Point, LineString or Polygon into a MultiPoint,
+// MultiLineString or MultiPolygon with a single part. Returns a clone of
+// geom for all other geometry types. The result is always a new geometry
+// owned by the caller, geom is not modified.
+func (g *Geos) ToMulti(geom *Geom) *Geom {
+	clone := g.Clone(geom)
+	if clone == nil {
+		return nil
+	}
+	switch g.TypeID(clone) {
+	case PointTypeID:
+		return g.MultiPoint([]*Geom{clone})
+	case LineStringTypeID:
+		return g.MultiLineString([]*Geom{clone})
+	case PolygonTypeID:
+		return g.MultiPolygon([]*Geom{clone})
+	default:
+		return clone
+	}
+}
+
+// RemoveEmpty removes all empty parts of a Multi* geometry or
+// GeometryCollection. Returns a clone of the part if only one part is left
+// and an empty GeometryCollection if all parts are empty. Returns a clone
+// of geom if it is not a collection or if it has no empty parts.
+// The result is always a new geometry owned by the caller, geom is not
+// modified.
+func (g *Geos) RemoveEmpty(geom *Geom) *Geom {
+	typeID := g.TypeID(geom)
+	switch typeID {
+	case MultiPointTypeID, MultiLineStringTypeID, MultiPolygonTypeID, GeometryCollectionTypeID:
+	default:
+		return g.Clone(geom)
+	}
+	parts := g.Geoms(geom)
+	nonEmpty := make([]*Geom, 0, len(parts))
+	for _, part := range parts {
+		if !g.IsEmpty(part) {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	if len(nonEmpty) == len(parts) {
+		return g.Clone(geom)
+	}
+	if len(nonEmpty) == 1 {
+		return g.Clone(nonEmpty[0])
+	}
+	if len(nonEmpty) == 0 {
+		return g.GeometryCollection(nil)
+	}
+	for i := range nonEmpty {
+		// parts are owned by geom
+		nonEmpty[i] = g.Clone(nonEmpty[i])
+		if nonEmpty[i] == nil {
+			for _, c := range nonEmpty[:i] {
+				g.Destroy(c)
+			}
+			return nil
+		}
+	}
+	switch typeID {
+	case MultiPointTypeID:
+		return g.MultiPoint(nonEmpty)
+	case MultiLineStringTypeID:
+		return g.MultiLineString(nonEmpty)
+	case MultiPolygonTypeID:
+		return g.MultiPolygon(nonEmpty)
+	default:
+		return g.GeometryCollection(nonEmpty)
+	}
 }
 
 func (g *Geos) IsValid(geom *Geom) bool {
@@ -250,6 +485,18 @@ func (g *Geos) IsValid(geom *Geom) bool {
 	return false
 }
 
+// IsValidReason returns the reason why geom is invalid (e.g.
+// "Self-intersection[5 5]"), or "Valid Geometry" for valid geometries.
+func (g *Geos) IsValidReason(geom *Geom) string {
+	reason := C.GEOSisValidReason_r(g.v, geom.v)
+	if reason == nil {
+		return ""
+	}
+	result := C.GoString(reason)
+	C.GEOSFree_r(g.v, unsafe.Pointer(reason))
+	return result
+}
+
 func (g *Geos) IsSimple(geom *Geom) bool {
 	if C.GEOSisSimple_r(g.v, geom.v) == 1 {
 		return true
@@ -273,6 +520,24 @@ func (g *Geos) Type(geom *Geom) string {
 	return C.GoString(geomType)
 }
 
+// Geometry type IDs as returned by TypeID.
+const (
+	PointTypeID              = int(C.GEOS_POINT)
+	LineStringTypeID         = int(C.GEOS_LINESTRING)
+	LinearRingTypeID         = int(C.GEOS_LINEARRING)
+	PolygonTypeID            = int(C.GEOS_POLYGON)
+	MultiPointTypeID         = int(C.GEOS_MULTIPOINT)
+	MultiLineStringTypeID    = int(C.GEOS_MULTILINESTRING)
+	MultiPolygonTypeID       = int(C.GEOS_MULTIPOLYGON)
+	GeometryCollectionTypeID = int(C.GEOS_GEOMETRYCOLLECTION)
+)
+
+// TypeID returns the geometry type ID (e.g. PolygonTypeID) or -1 on errors.
+// TypeID is cheaper than Type, as it does not allocate a string.
+func (g *Geos) TypeID(geom *Geom) int {
+	return int(C.GEOSGeomTypeId_r(g.v, geom.v))
+}
+
 func (g *Geos) Equals(a, b *Geom) bool {
 	result := C.GEOSEquals_r(g.v, a.v, b.v)
 	if result == 1 {
@@ -281,6 +546,75 @@ func (g *Geos) Equals(a, b *Geom) bool {
 	return false
 }
 
+// EqualsExact returns true if a and b have the same structure and all
+// coordinates are within tolerance.
+func (g *Geos) EqualsExact(a, b *Geom, tolerance float64) bool {
+	result := C.GEOSEqualsExact_r(g.v, a.v, b.v, C.double(tolerance))
+	if result == 1 {
+		return true
+	}
+	return false
+}
+
+// Equals2D is like Equals, but Z values of 3D geometries are dropped
+// before a and b are compared.
+func (g *Geos) Equals2D(a, b *Geom) bool {
+	if g.CoordDimension(a) == 3 {
+		if a = g.Force2D(a); a == nil {
+			return false
+		}
+		defer g.Destroy(a)
+	}
+	if g.CoordDimension(b) == 3 {
+		if b = g.Force2D(b); b == nil {
+			return false
+		}
+		defer g.Destroy(b)
+	}
+	return g.Equals(a, b)
+}
+
+// Force2D returns a copy of geom without Z values.
+// Returns nil on errors.
+func (g *Geos) Force2D(geom *Geom) *Geom {
+	var size C.size_t
+	// WKB output dimension is 2 by default
+	buf := C.GEOSGeomToWKB_buf_r(g.v, geom.v, &size)
+	if buf == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(buf))
+	result := C.GEOSGeomFromWKB_buf_r(g.v, buf, size)
+	if result == nil {
+		return nil
+	}
+	C.GEOSSetSRID_r(g.v, result, C.GEOSGetSRID_r(g.v, geom.v))
+	return newGeom(result)
+}
+
+// GeomHash returns a hash of the normalized geometry, e.g. to detect
+// changed geometries during diff updates. Geometries that only differ in
+// the start vertex or the orientation of their rings, or in the order of
+// their parts have the same hash. The SRID is not part of the hash.
+// Returns 0 on errors.
+func (g *Geos) GeomHash(geom *Geom) uint64 {
+	normalized := g.Clone(geom)
+	if normalized == nil {
+		return 0
+	}
+	defer g.Destroy(normalized)
+	if C.GEOSNormalize_r(g.v, normalized.v) != 0 {
+		return 0
+	}
+	wkb := g.AsWkbByteOrder(normalized, false)
+	if wkb == nil {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write(wkb)
+	return h.Sum64()
+}
+
 func (g *Geos) MakeValid(geom *Geom) (*Geom, error) {
 	if g.IsValid(geom) {
 		return geom, nil
@@ -294,6 +628,27 @@ func (g *Geos) MakeValid(geom *Geom) (*Geom, error) {
 	return fixed, nil
 }
 
+// BufferZeroRepair tries to fix an invalid geom (e.g. with self-intersections)
+// with buffer(0). Returns the buffered geometry if it is valid, or nil.
+// geom is not destroyed.
+func (g *Geos) BufferZeroRepair(geom *Geom) *Geom {
+	fixed := g.Buffer(geom, 0)
+	if fixed == nil {
+		return nil
+	}
+	if !g.IsValid(fixed) {
+		g.Destroy(fixed)
+		return nil
+	}
+	return fixed
+}
+
+// TypeID returns the geometry type ID like Geos.TypeID, but without a
+// handle (e.g. for geometry filters).
+func (g *Geom) TypeID() int {
+	return int(C.GEOSGeomTypeId(g.v))
+}
+
 func (g *Geom) Area() float64 {
 	var area C.double
 	if ret := C.GEOSArea(g.v, &area); ret == 1 {
@@ -302,6 +657,53 @@ func (g *Geom) Area() float64 {
 	return 0
 }
 
+// LargestPolygon returns the largest Polygon of a MultiPolygon or
+// GeometryCollection. The result is a clone, as the parts are owned by
+// geom. Returns geom itself if it is a Polygon, or nil if geom contains
+// no Polygon.
+func (g *Geos) LargestPolygon(geom *Geom) *Geom {
+	if g.TypeID(geom) == PolygonTypeID {
+		return geom
+	}
+	var largest *Geom
+	var largestArea float64
+	for _, part := range g.Geoms(geom) {
+		if g.TypeID(part) != PolygonTypeID {
+			continue
+		}
+		if area := part.Area(); largest == nil || area > largestArea {
+			largest = part
+			largestArea = area
+		}
+	}
+	if largest == nil {
+		return nil
+	}
+	return g.Clone(largest)
+}
+
+// SignedArea returns the signed area of a LinearRing or of the exterior
+// ring of a Polygon. The area is positive for counter-clockwise rings
+// and negative for clockwise rings.
+func (g *Geos) SignedArea(geom *Geom) float64 {
+	ring := geom
+	if g.TypeID(geom) == PolygonTypeID {
+		ring = g.ExteriorRing(geom)
+		if ring == nil {
+			return 0
+		}
+	}
+	coords, err := g.Coords(ring)
+	if err != nil || len(coords) < 3 {
+		return 0
+	}
+	var sum float64
+	for i := 0; i < len(coords)-1; i++ {
+		sum += coords[i][0]*coords[i+1][1] - coords[i+1][0]*coords[i][1]
+	}
+	return sum / 2
+}
+
 func (g *Geom) Length() float64 {
 	var length C.double
 	if ret := C.GEOSLength(g.v, &length); ret == 1 {
@@ -310,6 +712,17 @@ func (g *Geom) Length() float64 {
 	return 0
 }
 
+// zeroAreaEpsilon is relative to the squared length, so that IsZeroArea
+// is independent of the unit of the coordinates.
+const zeroAreaEpsilon = 1e-10
+
+// IsZeroArea returns true if the area of a (Multi)Polygon is nearly 0,
+// e.g. for collapsed polygons where all nodes are collinear.
+func (g *Geos) IsZeroArea(geom *Geom) bool {
+	length := geom.Length()
+	return geom.Area() <= zeroAreaEpsilon*length*length
+}
+
 type Bounds struct {
 	MinX float64
 	MinY float64
@@ -334,11 +747,20 @@ func (g *Geom) Bounds() Bounds {
 		return NilBounds
 	}
 	defer C.GEOSGeom_destroy(geom)
-	extRing := C.GEOSGetExteriorRing(geom)
-	if extRing == nil {
+	var cs *C.GEOSCoordSequence
+	if C.GEOSGeomTypeId(geom) == C.GEOS_POINT {
+		// envelope of a point (or of a single coordinate) is a point
+		cs = C.GEOSGeom_getCoordSeq(geom)
+	} else {
+		extRing := C.GEOSGetExteriorRing(geom)
+		if extRing == nil {
+			return NilBounds
+		}
+		cs = C.GEOSGeom_getCoordSeq(extRing)
+	}
+	if cs == nil {
 		return NilBounds
 	}
-	cs := C.GEOSGeom_getCoordSeq(extRing)
 	var csLen C.uint
 	C.GEOSCoordSeq_getSize(cs, &csLen)
 	minx := 1.e+20
@@ -367,3 +789,162 @@ func (g *Geom) Bounds() Bounds {
 
 	return Bounds{minx, miny, maxx, maxy}
 }
+
+// Extend returns the bounds that enclose b and other. Extending NilBounds
+// returns other.
+func (b Bounds) Extend(other Bounds) Bounds {
+	return Bounds{
+		MinX: math.Min(b.MinX, other.MinX),
+		MinY: math.Min(b.MinY, other.MinY),
+		MaxX: math.Max(b.MaxX, other.MaxX),
+		MaxY: math.Max(b.MaxY, other.MaxY),
+	}
+}
+
+const pole = 6378137 * math.Pi // 20037508.342789244
+
+// TileCover returns the x/y coordinates of all XYZ tiles at zoom that
+// cover the bounds. The bounds need to be in Web Mercator (EPSG:3857).
+// Bounds outside of the Web Mercator extent are clipped to it.
+func (b Bounds) TileCover(zoom int) [][2]int {
+	if b.MinX > b.MaxX || b.MinY > b.MaxY {
+		return nil
+	}
+	n := 1 << uint(zoom)
+	tile := func(v float64) int {
+		t := int(math.Floor(v / (2 * pole) * float64(n)))
+		if t < 0 {
+			return 0
+		}
+		if t >= n {
+			return n - 1
+		}
+		return t
+	}
+	minX, maxX := tile(b.MinX+pole), tile(b.MaxX+pole)
+	// tile rows start at the top
+	minY, maxY := tile(pole-b.MaxY), tile(pole-b.MinY)
+
+	tiles := make([][2]int, 0, (maxX-minX+1)*(maxY-minY+1))
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			tiles = append(tiles, [2]int{x, y})
+		}
+	}
+	return tiles
+}
+
+// MortonCode returns the Morton code (Z-order) of the center of the bounds,
+// with bits per axis (max. 32). The bounds need to be in Web Mercator
+// (EPSG:3857). Sorting by MortonCode keeps nearby bounds close together.
+func (b Bounds) MortonCode(bits int) uint64 {
+	if bits > 32 {
+		bits = 32
+	}
+	if bits < 1 {
+		return 0
+	}
+	n := float64(uint64(1) << uint(bits))
+	cell := func(v float64) uint64 {
+		c := math.Floor((v + pole) / (2 * pole) * n)
+		if c < 0 {
+			return 0
+		}
+		if c >= n {
+			return uint64(n) - 1
+		}
+		return uint64(c)
+	}
+	x := cell((b.MinX + b.MaxX) / 2)
+	y := cell((b.MinY + b.MaxY) / 2)
+
+	var code uint64
+	for i := uint(0); i < uint(bits); i++ {
+		code |= (x>>i&1)<<(2*i) | (y>>i&1)<<(2*i+1)
+	}
+	return code
+}
+
+// MortonCode returns the Morton code of the bounds of geom.
+// See Bounds.MortonCode.
+func (g *Geom) MortonCode(bits int) uint64 {
+	return g.Bounds().MortonCode(bits)
+}
+
+// Intersects returns true if both bounds intersect or touch.
+func (b Bounds) Intersects(other Bounds) bool {
+	return b.MinX <= other.MaxX && b.MaxX >= other.MinX &&
+		b.MinY <= other.MaxY && b.MaxY >= other.MinY
+}
+
+// Contains returns true if other is completely within b.
+func (b Bounds) Contains(other Bounds) bool {
+	return b.MinX <= other.MinX && b.MaxX >= other.MaxX &&
+		b.MinY <= other.MinY && b.MaxY >= other.MaxY
+}
+
+// Subtract returns the parts of b that are not covered by other, as up to
+// four non-overlapping bounds: the full-width parts below and above other
+// and the parts left and right of other. Returns b if both bounds do not
+// overlap and nil if b is completely covered by other.
+func (b Bounds) Subtract(other Bounds) []Bounds {
+	if !b.Intersects(other) {
+		return []Bounds{b}
+	}
+	if other.Contains(b) {
+		return nil
+	}
+	// o is the intersection of b and other
+	o := Bounds{
+		MinX: math.Max(b.MinX, other.MinX),
+		MinY: math.Max(b.MinY, other.MinY),
+		MaxX: math.Min(b.MaxX, other.MaxX),
+		MaxY: math.Min(b.MaxY, other.MaxY),
+	}
+	var parts []Bounds
+	if o.MinY > b.MinY {
+		parts = append(parts, Bounds{b.MinX, b.MinY, b.MaxX, o.MinY})
+	}
+	if o.MaxY < b.MaxY {
+		parts = append(parts, Bounds{b.MinX, o.MaxY, b.MaxX, b.MaxY})
+	}
+	if o.MinX > b.MinX {
+		parts = append(parts, Bounds{b.MinX, o.MinY, o.MinX, o.MaxY})
+	}
+	if o.MaxX < b.MaxX {
+		parts = append(parts, Bounds{o.MaxX, o.MinY, b.MaxX, o.MaxY})
+	}
+	return parts
+}
+
+// GeomInBounds returns true if geom intersects the bounds rectangle.
+// The envelope of geom is checked first and the bounds polygon
+// is only created if the envelope overlaps the bounds partially.
+func (g *Geos) GeomInBounds(geom *Geom, bounds Bounds) bool {
+	if result, ok := envelopeInBounds(geom.Bounds(), bounds); ok {
+		return result
+	}
+	// no envelope for empty geometries, or partial overlap
+	boundsGeom := g.BoundsPolygon(bounds)
+	if boundsGeom == nil {
+		return false
+	}
+	defer g.Destroy(boundsGeom)
+	return g.Intersects(boundsGeom, geom)
+}
+
+// envelopeInBounds returns whether a geometry with the envelope geomBounds
+// intersects bounds. ok is false if this can not be decided by the envelope
+// alone.
+func envelopeInBounds(geomBounds, bounds Bounds) (result, ok bool) {
+	if geomBounds == NilBounds {
+		return false, false
+	}
+	if !bounds.Intersects(geomBounds) {
+		return false, true
+	}
+	if bounds.Contains(geomBounds) {
+		return true, true
+	}
+	return false, false
+}
@@ -1,9 +1,16 @@
 package geos
 
 import (
+	"bytes"
+	"encoding/hex"
 	"fmt"
-
+	"math"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestFoo(t *testing.T) {
@@ -87,3 +94,2019 @@ func BenchmarkIndexQuery(b *testing.B) {
 	// }
 
 }
+
+func TestPreparedCache(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	cache := NewPreparedCache(2)
+	defer g.DestroyPreparedCache(cache)
+
+	a := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+	b := g.FromWkt("POLYGON((20 0, 30 0, 30 10, 20 10, 20 0))")
+	c := g.FromWkt("POLYGON((40 0, 50 0, 50 10, 40 10, 40 0))")
+
+	prepA := g.PrepareCached(cache, a)
+	if prepA == nil {
+		t.Fatal("unable to prepare")
+	}
+	if p := g.PrepareCached(cache, a); p != prepA {
+		t.Error("cached geometry prepared again")
+	}
+	if !g.PreparedContains(prepA, g.Point(5, 5)) {
+		t.Error("cached prepared geometry does not contain point")
+	}
+
+	prepB := g.PrepareCached(cache, b)
+	// a is used again and b is the least recently used entry
+	if p := g.PrepareCached(cache, a); p != prepA {
+		t.Error("cached geometry prepared again")
+	}
+	g.PrepareCached(cache, c)
+	if p := g.PrepareCached(cache, a); p != prepA {
+		t.Error("recently used geometry evicted")
+	}
+	if prepB.v != nil {
+		t.Error("least recently used geometry not destroyed")
+	}
+	if p := g.PrepareCached(cache, b); p == prepB {
+		t.Error("evicted geometry returned from cache")
+	}
+}
+
+func batchTestPoints(g *Geos, n int) []*Geom {
+	points := make([]*Geom, n)
+	for i := range points {
+		points[i] = g.Point(float64(i%40), float64(i/40%40))
+	}
+	return points
+}
+
+func TestPreparedIntersectsBatch(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	prep := g.Prepare(g.FromWkt("POLYGON((0 0, 20 0, 20 20, 0 20, 0 0))"))
+	defer g.PreparedDestroy(prep)
+
+	points := batchTestPoints(g, 1000)
+	result := g.PreparedIntersectsBatch(prep, points)
+	if len(result) != len(points) {
+		t.Fatal("unexpected result length", len(result))
+	}
+	hits := 0
+	for i, p := range points {
+		if result[i] != g.PreparedIntersects(prep, p) {
+			t.Errorf("unexpected result for %s", g.AsWkt(p))
+		}
+		if result[i] {
+			hits++
+		}
+	}
+	if hits == 0 || hits == len(points) {
+		t.Error("unexpected number of hits", hits)
+	}
+}
+
+func BenchmarkPreparedIntersects(b *testing.B) {
+	g := NewGeos()
+	defer g.Finish()
+	prep := g.Prepare(g.FromWkt("POLYGON((0 0, 20 0, 20 20, 0 20, 0 0))"))
+	points := batchTestPoints(g, 1000)
+
+	b.Run("single", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			result := make([]bool, len(points))
+			for j, p := range points {
+				result[j] = g.PreparedIntersects(prep, p)
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			g.PreparedIntersectsBatch(prep, points)
+		}
+	})
+}
+
+func TestVersion(t *testing.T) {
+	if Version() == "" {
+		t.Fatal("empty version")
+	}
+	if !VersionAtLeast(3, 0) {
+		t.Error("version not >= 3.0", Version())
+	}
+	if VersionAtLeast(99, 0) {
+		t.Error("version >= 99.0", Version())
+	}
+
+	for _, tc := range []struct {
+		version      string
+		major, minor int
+		ok           bool
+	}{
+		{"3.11.1-CAPI-1.17.1", 3, 11, true},
+		{"3.12.0dev-CAPI-1.18.0", 3, 12, true},
+		{"3.9rc1", 3, 9, true},
+		{"foo", 0, 0, false},
+	} {
+		major, minor, ok := parseVersion(tc.version)
+		if major != tc.major || minor != tc.minor || ok != tc.ok {
+			t.Errorf("unexpected result for %q: %d %d %v", tc.version, major, minor, ok)
+		}
+	}
+}
+
+func TestCaps(t *testing.T) {
+	caps := Caps()
+	if caps.ClipByRect != VersionAtLeast(3, 5) {
+		t.Error("unexpected ClipByRect", caps, Version())
+	}
+	if caps.MakeValid != VersionAtLeast(3, 8) {
+		t.Error("unexpected MakeValid", caps, Version())
+	}
+	if caps.GeoJSON != VersionAtLeast(3, 10) {
+		t.Error("unexpected GeoJSON", caps, Version())
+	}
+	if caps.CoordSeqBuffer != VersionAtLeast(3, 10) {
+		t.Error("unexpected CoordSeqBuffer", caps, Version())
+	}
+}
+
+func TestClipByRect(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	geom := g.FromWkt("LINESTRING(0 5, 20 5)")
+	clipped, err := g.ClipByRect(geom, MakeBounds(0, 0, 10, 10))
+	if !Caps().ClipByRect {
+		if err == nil {
+			t.Fatal("expected unsupported error")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clipped.Length() != 10 {
+		t.Error("unexpected length", g.AsWkt(clipped))
+	}
+}
+
+func TestCreateCoordSeqDim(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	for _, dim := range []uint32{0, 1, 4} {
+		cs, err := g.CreateCoordSeq(2, dim)
+		if err == nil {
+			t.Errorf("no error for dim %d", dim)
+			g.DestroyCoordSeq(cs)
+			continue
+		}
+		if _, ok := err.(CreateError); !ok {
+			t.Errorf("unexpected error type %T", err)
+		}
+	}
+	for _, dim := range []uint32{2, 3} {
+		cs, err := g.CreateCoordSeq(2, dim)
+		if err != nil {
+			t.Errorf("error for dim %d: %s", dim, err)
+			continue
+		}
+		g.DestroyCoordSeq(cs)
+	}
+	cs, err := g.CreateCoordSeq(0, 2)
+	if err != nil {
+		t.Error("error for empty coord seq:", err)
+	} else {
+		g.DestroyCoordSeq(cs)
+	}
+}
+
+func TestMultiPolygonTypes(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	poly := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+	line := g.FromWkt("LINESTRING(0 0, 10 0)")
+	if g.TypeID(poly) != PolygonTypeID || g.TypeID(line) != LineStringTypeID {
+		t.Fatal("unexpected type ids", g.TypeID(poly), g.TypeID(line))
+	}
+
+	baseline := settledLiveGeomCount()
+	if mp := g.MultiPolygon([]*Geom{poly, line}); mp != nil {
+		t.Fatal("MultiPolygon with LineString not rejected", g.AsWkt(mp))
+	}
+	// rejected geometries are destroyed
+	if c := LiveGeomCount(); c != baseline-2 {
+		t.Error("rejected geometries not destroyed", c-baseline)
+	}
+
+	poly = g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+	mp := g.MultiPolygon([]*Geom{poly})
+	if mp == nil {
+		t.Fatal("unable to create MultiPolygon")
+	}
+	if g.TypeID(mp) != MultiPolygonTypeID {
+		t.Error("unexpected type", g.Type(mp))
+	}
+}
+
+func TestMultiPoint(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	mp := g.MultiPoint([]*Geom{g.Point(0, 0), g.Point(1, 1), g.Point(2, 2)})
+	if mp == nil {
+		t.Fatal("unable to create MultiPoint")
+	}
+	if n := g.NumGeoms(mp); n != 3 {
+		t.Error("unexpected number of geometries", n)
+	}
+
+	point := g.Point(0, 0)
+	line := g.FromWkt("LINESTRING(0 0, 1 1)")
+	baseline := settledLiveGeomCount()
+	if mp := g.MultiPoint([]*Geom{point, line}); mp != nil {
+		t.Error("MultiPoint with LineString not rejected", g.AsWkt(mp))
+	}
+	// rejected geometries are destroyed
+	if c := LiveGeomCount(); c != baseline-2 {
+		t.Error("rejected geometries not destroyed", c-baseline)
+	}
+}
+
+func TestGeometryCollection(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	gc := g.GeometryCollection([]*Geom{
+		g.Point(0, 0),
+		g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))"),
+	})
+	if gc == nil {
+		t.Fatal("unable to create GeometryCollection")
+	}
+	parts := g.Geoms(gc)
+	if len(parts) != 2 || g.Type(parts[0]) != "Point" || g.Type(parts[1]) != "Polygon" {
+		t.Error("unexpected parts", g.AsWkt(gc))
+	}
+
+	empty := g.GeometryCollection(nil)
+	if empty == nil {
+		t.Fatal("unable to create empty GeometryCollection")
+	}
+	if !g.IsEmpty(empty) || g.TypeID(empty) != GeometryCollectionTypeID {
+		t.Error("unexpected empty collection", g.AsWkt(empty))
+	}
+}
+
+func benchmarkIndexCapacity(b *testing.B, nodeCapacity int) {
+	g := NewGeos()
+	defer g.Finish()
+
+	idx, err := g.CreateIndexWithCapacity(nodeCapacity)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 100; y++ {
+			p := g.FromWkt(fmt.Sprintf("POLYGON((%d %d, %d %d, %d %d, %d %d, %d %d))",
+				x, y, x+1, y, x+1, y+1, x, y+1, x, y))
+			if p == nil {
+				b.Fatal()
+			}
+			g.IndexAdd(idx, p)
+		}
+	}
+	point := g.Point(50.5, 50.5)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if geoms := g.IndexQuery(idx, point); len(geoms) != 1 {
+			b.Fatal(geoms)
+		}
+	}
+}
+
+func TestCreateIndexWithCapacity(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	if _, err := g.CreateIndexWithCapacity(1); err == nil {
+		t.Error("node capacity of 1 not rejected")
+	}
+	idx, err := g.CreateIndexWithCapacity(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.IndexAdd(idx, g.Point(0, 0))
+	if hits := g.IndexQuery(idx, g.Point(0, 0)); len(hits) != 1 {
+		t.Error("unexpected hits", hits)
+	}
+}
+
+func BenchmarkIndexCapacity4(b *testing.B)  { benchmarkIndexCapacity(b, 4) }
+func BenchmarkIndexCapacity10(b *testing.B) { benchmarkIndexCapacity(b, 10) }
+func BenchmarkIndexCapacity50(b *testing.B) { benchmarkIndexCapacity(b, 50) }
+
+func TestIndexBounds(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	idx := g.CreateIndex()
+	if b := g.IndexBounds(idx); b != NilBounds {
+		t.Error("unexpected bounds for empty index", b)
+	}
+
+	g.IndexAdd(idx, g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))"))
+	g.IndexAdd(idx, g.FromWkt("LINESTRING(20 -5, 30 5)"))
+	g.IndexAdd(idx, g.FromWkt("POINT(-10 40)"))
+
+	if b := g.IndexBounds(idx); b != MakeBounds(-10, -5, 30, 40) {
+		t.Error("unexpected bounds", b)
+	}
+}
+
+func TestBoundsTileCover(t *testing.T) {
+	tiles := MakeBounds(1, 1, 10, 10).TileCover(14)
+	if !reflect.DeepEqual(tiles, [][2]int{{8192, 8191}}) {
+		t.Error("unexpected tiles", tiles)
+	}
+	tiles = MakeBounds(-10, -10, 10, 10).TileCover(14)
+	if !reflect.DeepEqual(tiles, [][2]int{{8191, 8191}, {8191, 8192}, {8192, 8191}, {8192, 8192}}) {
+		t.Error("unexpected tiles", tiles)
+	}
+	tiles = MakeBounds(-3e7, -3e7, 3e7, 3e7).TileCover(1)
+	if len(tiles) != 4 {
+		t.Error("unexpected tiles", tiles)
+	}
+	if tiles := NilBounds.TileCover(14); tiles != nil {
+		t.Error("unexpected tiles for NilBounds", tiles)
+	}
+}
+
+func TestBoundsMortonCode(t *testing.T) {
+	a := MakeBounds(1000, 1000, 1100, 1100).MortonCode(16)
+	b := MakeBounds(1200, 1000, 1300, 1100).MortonCode(16)
+	c := MakeBounds(5e6, 5e6, 5e6+100, 5e6+100).MortonCode(16)
+	diff := func(a, b uint64) uint64 {
+		if a > b {
+			return a - b
+		}
+		return b - a
+	}
+	if diff(a, b) >= diff(a, c) {
+		t.Error("nearby bounds not closer than distant bounds", a, b, c)
+	}
+
+	// lower left and upper right corner of the world
+	if code := MakeBounds(-pole, -pole, -pole, -pole).MortonCode(32); code != 0 {
+		t.Error("unexpected code", code)
+	}
+	if code := MakeBounds(pole, pole, pole, pole).MortonCode(32); code != math.MaxUint64 {
+		t.Error("unexpected code", code)
+	}
+
+	g := NewGeos()
+	defer g.Finish()
+	if code := g.FromWkt("POLYGON((1000 1000, 1100 1000, 1100 1100, 1000 1100, 1000 1000))").MortonCode(16); code != a {
+		t.Error("unexpected code for geometry", code, a)
+	}
+}
+
+func TestSharedBoundaryLength(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	square := g.FromWkt("POLYGON((0 0, 1 0, 1 1, 0 1, 0 0))")
+	for _, test := range []struct {
+		wkt      string
+		expected float64
+	}{
+		{"POLYGON((1 0, 2 0, 2 1, 1 1, 1 0))", 1},
+		// partially shared edge
+		{"POLYGON((1 0.5, 2 0.5, 2 2, 1 2, 1 0.5))", 0.5},
+		// touches at corner
+		{"POLYGON((1 1, 2 1, 2 2, 1 2, 1 1))", 0},
+		{"POLYGON((5 5, 6 5, 6 6, 5 6, 5 5))", 0},
+		{"MULTIPOLYGON(((1 0, 2 0, 2 1, 1 1, 1 0)), ((-1 0, 0 0, 0 1, -1 1, -1 0)))", 2},
+	} {
+		if l := g.SharedBoundaryLength(square, g.FromWkt(test.wkt)); l != test.expected {
+			t.Errorf("%s: %v != %v", test.wkt, l, test.expected)
+		}
+	}
+}
+
+func TestGeomChanged(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	poly := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+	for _, test := range []struct {
+		wkt     string
+		changed bool
+	}{
+		{"POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))", false},
+		// same polygon with other start point
+		{"POLYGON((10 0, 10 10, 0 10, 0 0, 10 0))", false},
+		{"POLYGON((0 0, 10.05 0, 10 10, 0 10.05, 0 0))", false},
+		{"POLYGON((0 0, 10.5 0, 10 10, 0 10, 0 0))", true},
+		{"POLYGON((5 0, 15 0, 15 10, 5 10, 5 0))", true},
+		{"POLYGON EMPTY", true},
+	} {
+		if changed := g.GeomChanged(poly, g.FromWkt(test.wkt), 0.1); changed != test.changed {
+			t.Errorf("%s: %v != %v", test.wkt, changed, test.changed)
+		}
+	}
+	if g.GeomChanged(g.FromWkt("POINT EMPTY"), g.FromWkt("POINT EMPTY"), 0.1) {
+		t.Error("empty geometries changed")
+	}
+
+	dist, err := g.HausdorffDistance(g.FromWkt("LINESTRING(0 0, 10 0)"), g.FromWkt("LINESTRING(0 1, 10 3)"))
+	if err != nil || dist != 3 {
+		t.Error("unexpected distance", dist, err)
+	}
+}
+
+func TestEquals2D(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	p2d := g.FromWkt("POINT(1 2)")
+	p3d := g.FromWkt("POINT Z(1 2 3)")
+	if !g.Equals2D(p2d, p3d) || !g.Equals2D(p3d, p2d) {
+		t.Error("2D and 3D point not equal")
+	}
+	if g.Equals2D(p2d, g.FromWkt("POINT Z(1 3 3)")) {
+		t.Error("different points are equal")
+	}
+	if !g.Equals2D(g.FromWkt("LINESTRING Z(0 0 1, 10 0 2)"), g.FromWkt("LINESTRING(0 0, 10 0)")) {
+		t.Error("2D and 3D line not equal")
+	}
+
+	flat := g.Force2D(p3d)
+	if g.CoordDimension(flat) != 2 || !g.Equals(flat, p2d) {
+		t.Error("unexpected geometry", g.AsWkt(flat))
+	}
+	if g.CoordDimension(p3d) != 3 {
+		t.Error("original geometry modified")
+	}
+}
+
+func TestRingContains(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	outer := g.FromWkt("LINEARRING(0 0, 100 0, 100 100, 0 100, 0 0)")
+	for _, test := range []struct {
+		wkt      string
+		expected bool
+	}{
+		{"LINEARRING(10 10, 20 10, 20 20, 10 20, 10 10)", true},
+		{"LINESTRING(10 10, 20 10, 20 20, 10 20, 10 10)", true},
+		{"POLYGON((10 10, 20 10, 20 20, 10 20, 10 10))", true},
+		// touches outer ring at single point
+		{"LINEARRING(0 50, 20 40, 20 60, 0 50)", true},
+		// touches outer ring along a segment
+		{"LINEARRING(0 10, 20 10, 20 20, 0 20, 0 10)", true},
+		{"LINEARRING(110 10, 120 10, 120 20, 110 20, 110 10)", false},
+		// crosses outer ring
+		{"LINEARRING(90 10, 120 10, 120 20, 90 20, 90 10)", false},
+		// contains outer ring
+		{"LINEARRING(-10 -10, 110 -10, 110 110, -10 110, -10 -10)", false},
+		// not closed
+		{"LINESTRING(10 10, 20 10, 20 20)", false},
+	} {
+		if result := g.RingContains(outer, g.FromWkt(test.wkt)); result != test.expected {
+			t.Errorf("%s: %v != %v", test.wkt, result, test.expected)
+		}
+	}
+}
+
+func TestHasSelfTouch(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	for _, test := range []struct {
+		wkt      string
+		expected bool
+	}{
+		{"LINESTRING(0 0, 10 0, 10 10, 0 10, 0 0)", false},
+		{"LINEARRING(0 0, 10 0, 10 10, 0 10, 0 0)", false},
+		// figure-eight
+		{"LINESTRING(0 0, 10 0, 10 10, 20 10, 20 20, 10 20, 10 10, 0 10, 0 0)", true},
+		{"LINEARRING(0 0, 10 0, 10 10, 20 10, 20 20, 10 20, 10 10, 0 10, 0 0)", true},
+		{"LINESTRING(0 0, 10 0, 10 10)", false},
+		{"POINT(0 0)", false},
+	} {
+		if result := g.HasSelfTouch(g.FromWkt(test.wkt)); result != test.expected {
+			t.Errorf("%s: %v != %v", test.wkt, result, test.expected)
+		}
+	}
+}
+
+func TestPolygonizeRing(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	ring := g.FromWkt("LINEARRING(0 0, 10 0, 10 10, 20 10, 20 20, 10 20, 10 10, 0 10, 0 0)")
+	result := g.PolygonizeRing(ring)
+	if result == nil {
+		t.Fatal("no result")
+	}
+	if g.TypeID(result) != MultiPolygonTypeID || g.NumGeoms(result) != 2 || result.Area() != 200 {
+		t.Error("unexpected result", g.AsWkt(result))
+	}
+
+	// loop inside of the ring
+	ring = g.FromWkt("LINEARRING(0 0, 10 0, 10 10, 5 10, 7 7, 3 7, 5 10, 0 10, 0 0)")
+	result = g.PolygonizeRing(ring)
+	if result == nil {
+		t.Fatal("no result")
+	}
+	if !g.IsValid(result) || len(g.InteriorRings(result)) != 1 || result.Area() != 94 {
+		t.Error("unexpected result", g.AsWkt(result))
+	}
+
+	if g.PolygonizeRing(g.FromWkt("LINEARRING(0 0, 5 5, 10 10, 5 5, 0 0)")) != nil {
+		t.Error("collapsed ring not rejected")
+	}
+}
+
+func TestBoundsSubtract(t *testing.T) {
+	area := func(b Bounds) float64 { return (b.MaxX - b.MinX) * (b.MaxY - b.MinY) }
+	overlap := func(a, b Bounds) float64 {
+		w := math.Min(a.MaxX, b.MaxX) - math.Max(a.MinX, b.MinX)
+		h := math.Min(a.MaxY, b.MaxY) - math.Max(a.MinY, b.MinY)
+		if w <= 0 || h <= 0 {
+			return 0
+		}
+		return w * h
+	}
+
+	b := MakeBounds(0, 0, 100, 100)
+	for _, test := range []struct {
+		other     Bounds
+		numParts  int
+		remaining float64
+	}{
+		{MakeBounds(40, 40, 60, 60), 4, 10000 - 400},
+		// L-shape
+		{MakeBounds(50, 50, 150, 150), 2, 10000 - 2500},
+		{MakeBounds(-10, 50, 110, 150), 1, 5000},
+		{MakeBounds(200, 200, 300, 300), 1, 10000},
+		{MakeBounds(-10, -10, 110, 110), 0, 0},
+	} {
+		parts := b.Subtract(test.other)
+		if len(parts) != test.numParts {
+			t.Error("unexpected parts", test.other, parts)
+			continue
+		}
+		sum := 0.0
+		for i, p := range parts {
+			if !b.Contains(p) || overlap(p, test.other) != 0 {
+				t.Error("part not in remaining area", test.other, p)
+			}
+			for _, q := range parts[i+1:] {
+				if overlap(p, q) != 0 {
+					t.Error("overlapping parts", p, q)
+				}
+			}
+			sum += area(p)
+		}
+		if sum != test.remaining {
+			t.Error("unexpected area of parts", test.other, sum)
+		}
+	}
+}
+
+func TestIndexSnapshot(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	idx := g.CreateIndex()
+	g.IndexAdd(idx, g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))"))
+	g.IndexAdd(idx, g.FromWkt("POLYGON((20 0, 30 0, 30 10, 20 10, 20 0))"))
+
+	snapshot := g.IndexSnapshot(idx)
+	g.IndexAdd(idx, g.FromWkt("POLYGON((0 0, 30 0, 30 10, 0 10, 0 0))"))
+
+	if hits := g.IndexQuery(idx, g.Point(5, 5)); len(hits) != 2 {
+		t.Error("unexpected hits in index", hits)
+	}
+	if hits := g.IndexQuery(snapshot, g.Point(5, 5)); !reflect.DeepEqual(hits, []int{0}) {
+		t.Error("unexpected hits in snapshot", hits)
+	}
+	geoms := g.IndexQueryGeoms(snapshot, g.Point(25, 5))
+	if len(geoms) != 1 || !g.Equals(geoms[0].Geom, g.FromWkt("POLYGON((20 0, 30 0, 30 10, 20 10, 20 0))")) {
+		t.Error("unexpected geoms in snapshot", geoms)
+	}
+
+	// clones of the snapshot are destroyed with the snapshot
+	baseline := settledLiveGeomCount()
+	g.DestroyIndex(snapshot)
+	if c := LiveGeomCount(); c != baseline-2 {
+		t.Error("snapshot geometries not destroyed", c-baseline)
+	}
+	// geometries of the original index are not destroyed
+	g.DestroyIndex(idx)
+	if c := LiveGeomCount(); c != baseline-2 {
+		t.Error("index geometries destroyed", c-baseline)
+	}
+}
+
+func TestIndexContainingPolygons(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	idx := g.CreateIndex()
+	// triangles with overlapping envelopes
+	a := g.FromWkt("POLYGON((0 0, 10 0, 0 10, 0 0))")
+	b := g.FromWkt("POLYGON((10 0, 10 10, 0 10, 10 0))")
+	g.IndexAdd(idx, a)
+	g.IndexAdd(idx, b)
+
+	if hits := g.IndexQuery(idx, g.Point(2, 2)); len(hits) != 2 {
+		t.Fatal("expected two candidates", hits)
+	}
+	for i := 0; i < 2; i++ { // second run with cached prepared geoms
+		hits := g.IndexContainingPolygons(idx, g.Point(2, 2))
+		if len(hits) != 1 || hits[0].Geom != a {
+			t.Error("unexpected hits", hits)
+		}
+		hits = g.IndexContainingPolygons(idx, g.Point(8, 8))
+		if len(hits) != 1 || hits[0].Geom != b {
+			t.Error("unexpected hits", hits)
+		}
+	}
+	if hits := g.IndexContainingPolygons(idx, g.Point(20, 20)); len(hits) != 0 {
+		t.Error("unexpected hits", hits)
+	}
+	if hits := g.IndexContainingPolygons(idx, g.Point(8, 8)); len(hits) != 1 || hits[0].Index != 1 {
+		t.Error("unexpected index of hit", hits)
+	}
+
+	// concurrent lookups with one handle for each goroutine
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := NewGeos()
+			defer g.Finish()
+			point := g.Point(2, 2)
+			for j := 0; j < 100; j++ {
+				if hits := g.IndexContainingPolygons(idx, point); len(hits) != 1 || hits[0].Geom != a {
+					t.Error("unexpected hits", hits)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	prepared := idx.prepared[0]
+	g.DestroyIndex(idx)
+	if prepared.prep != nil {
+		t.Error("prepared geometry not destroyed")
+	}
+}
+
+func TestSignedArea(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	ccw := g.FromWkt("LINEARRING(0 0, 10 0, 10 10, 0 10, 0 0)")
+	cw := g.FromWkt("LINEARRING(0 0, 0 10, 10 10, 10 0, 0 0)")
+	if a := g.SignedArea(ccw); a != 100 {
+		t.Error("unexpected area for CCW ring", a)
+	}
+	if a := g.SignedArea(cw); a != -100 {
+		t.Error("unexpected area for CW ring", a)
+	}
+
+	poly := g.FromWkt("POLYGON((0 0, 0 10, 10 10, 10 0, 0 0))")
+	if a := g.SignedArea(poly); a != -100 {
+		t.Error("unexpected area for CW polygon", a)
+	}
+}
+
+func TestCoordDimension(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	point := g.FromWkt("POINT Z (1 2 3)")
+	if d := g.CoordDimension(point); d != 3 {
+		t.Error("unexpected dimension for 3D point", d)
+	}
+	line := g.FromWkt("LINESTRING(0 0, 10 10)")
+	if d := g.CoordDimension(line); d != 2 {
+		t.Error("unexpected dimension for 2D line", d)
+	}
+
+	// Z is preserved in WKB
+	decoded := g.FromWkb(g.AsWkb(point))
+	if decoded == nil {
+		t.Fatal("unable to decode WKB")
+	}
+	if d := g.CoordDimension(decoded); d != 3 {
+		t.Error("Z not preserved in WKB", g.AsWkt(decoded))
+	}
+	decoded = g.FromWkb(g.AsWkb(line))
+	if d := g.CoordDimension(decoded); d != 2 {
+		t.Error("unexpected dimension for 2D WKB", g.AsWkt(decoded))
+	}
+}
+
+func TestBufferZeroRepair(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	bowtie := g.FromWkt("POLYGON((0 0, 10 10, 10 0, 0 10, 0 0))")
+	if g.IsValid(bowtie) {
+		t.Fatal("bowtie is valid")
+	}
+	fixed := g.BufferZeroRepair(bowtie)
+	if fixed == nil {
+		t.Fatal("unable to repair bowtie")
+	}
+	if !g.IsValid(fixed) {
+		t.Error("repaired geometry is invalid", g.AsWkt(fixed))
+	}
+	if fixed.Area() <= 0 {
+		t.Error("repaired geometry is empty", g.AsWkt(fixed))
+	}
+}
+
+func TestGeomInBounds(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	bounds := MakeBounds(0, 0, 10, 10)
+	for _, tc := range []struct {
+		wkt      string
+		expected bool
+		// envelope is true if the result is decided by the envelope,
+		// without BoundsPolygon and Intersects
+		envelope bool
+	}{
+		{"LINESTRING(20 20, 30 30)", false, true},                     // outside
+		{"LINESTRING(2 2, 8 8)", true, true},                          // inside
+		{"LINESTRING(5 5, 15 15)", true, false},                       // partial
+		{"LINESTRING(11 5, 15 -1)", false, true},                      // outside, no envelope overlap
+		{"LINESTRING(8 20, 20 8)", false, false},                      // envelope overlaps, geometry not
+		{"POLYGON((-5 -5, 15 -5, 15 15, -5 15, -5 -5))", true, false}, // covers bounds
+		{"POINT(5 5)", true, true},
+		{"POINT(50 5)", false, true},
+		{"POINT EMPTY", false, false},
+	} {
+		geom := g.FromWkt(tc.wkt)
+		if result := g.GeomInBounds(geom, bounds); result != tc.expected {
+			t.Errorf("unexpected result for %s: %v", tc.wkt, result)
+		}
+		result, ok := envelopeInBounds(geom.Bounds(), bounds)
+		if ok != tc.envelope || (ok && result != tc.expected) {
+			t.Errorf("unexpected envelope check for %s: %v %v", tc.wkt, result, ok)
+		}
+	}
+
+	if !bounds.Intersects(MakeBounds(10, 10, 20, 20)) {
+		t.Error("touching bounds do not intersect")
+	}
+	if bounds.Intersects(MakeBounds(10.1, 0, 20, 10)) {
+		t.Error("disjoint bounds intersect")
+	}
+}
+
+func TestGeoJSON(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	geom, err := g.FromGeoJSON(`{"type": "Polygon", "coordinates": [[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]]]}`)
+	if !Caps().GeoJSON {
+		if err == nil {
+			t.Fatal("expected unsupported error")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if geom.Area() != 100 {
+		t.Fatal("unexpected area", geom.Area())
+	}
+
+	geojson, err := g.AsGeoJSON(geom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundtrip, err := g.FromGeoJSON(geojson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Equals(geom, roundtrip) {
+		t.Error("geometry changed after roundtrip", geojson)
+	}
+
+	if _, err := g.FromGeoJSON(`{"type": "Polygon"`); err == nil {
+		t.Error("invalid GeoJSON not rejected")
+	}
+}
+
+func TestSplitAtAntimeridian(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	parts := g.SplitAtAntimeridian(g.FromWkt("LINESTRING(179 10, -179 20)"))
+	if len(parts) != 2 {
+		t.Fatal("expected two parts", parts)
+	}
+	for i, expected := range []string{
+		"LINESTRING (179 10, 180 15)",
+		"LINESTRING (-180 15, -179 20)",
+	} {
+		if !g.Equals(parts[i], g.FromWkt(expected)) {
+			t.Errorf("unexpected part %d: %s", i, g.AsWkt(parts[i]))
+		}
+	}
+
+	parts = g.SplitAtAntimeridian(g.FromWkt("LINESTRING(-170 0, 170 0, 160 0)"))
+	if len(parts) != 2 || parts[0].Length() != 10 || parts[1].Length() != 20 {
+		t.Error("unexpected parts for westward line", len(parts))
+	}
+
+	line := g.FromWkt("LINESTRING(10 10, 20 20)")
+	parts = g.SplitAtAntimeridian(line)
+	if len(parts) != 1 || !g.Equals(parts[0], line) {
+		t.Error("line without crossing was split")
+	} else if parts[0] == line {
+		t.Error("expected clone of line without crossing")
+	}
+}
+
+func TestLargestPolygon(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	multi := g.FromWkt("MULTIPOLYGON(((0 0, 1 0, 1 1, 0 1, 0 0)), ((10 10, 20 10, 20 20, 10 20, 10 10)), ((30 30, 32 30, 32 32, 30 32, 30 30)))")
+	largest := g.LargestPolygon(multi)
+	if largest == nil {
+		t.Fatal("no polygon returned")
+	}
+	if g.Type(largest) != "Polygon" || largest.Area() != 100 {
+		t.Error("unexpected polygon", g.AsWkt(largest))
+	}
+
+	polygon := g.FromWkt("POLYGON((0 0, 1 0, 1 1, 0 1, 0 0))")
+	if g.LargestPolygon(polygon) != polygon {
+		t.Error("polygon not returned as is")
+	}
+	if g.LargestPolygon(g.FromWkt("LINESTRING(0 0, 10 10)")) != nil {
+		t.Error("linestring returned")
+	}
+}
+
+func TestRemoveEmpty(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	coll := g.FromWkt("GEOMETRYCOLLECTION(POLYGON EMPTY, POLYGON((0 0, 10 0, 10 10, 0 10, 0 0)))")
+	result := g.RemoveEmpty(coll)
+	if g.Type(result) != "Polygon" || result.Area() != 100 {
+		t.Error("unexpected result", g.AsWkt(result))
+	}
+
+	multi := g.FromWkt("MULTIPOLYGON(EMPTY, ((0 0, 1 0, 1 1, 0 1, 0 0)), ((5 5, 6 5, 6 6, 5 6, 5 5)))")
+	result = g.RemoveEmpty(multi)
+	if g.Type(result) != "MultiPolygon" || g.NumGeoms(result) != 2 {
+		t.Error("unexpected result", g.AsWkt(result))
+	}
+
+	result = g.RemoveEmpty(g.FromWkt("GEOMETRYCOLLECTION(POINT EMPTY, LINESTRING EMPTY)"))
+	if !g.IsEmpty(result) || g.NumGeoms(result) != 0 {
+		t.Error("unexpected result", g.AsWkt(result))
+	}
+
+	polygon := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+	result = g.RemoveEmpty(polygon)
+	if result == polygon || !g.Equals(result, polygon) {
+		t.Error("polygon not returned as new geometry", g.AsWkt(result))
+	}
+
+	multi = g.FromWkt("MULTIPOLYGON(((0 0, 1 0, 1 1, 0 1, 0 0)), ((5 5, 6 5, 6 6, 5 6, 5 5)))")
+	result = g.RemoveEmpty(multi)
+	if result == multi || !g.Equals(result, multi) {
+		t.Error("multipolygon not returned as new geometry", g.AsWkt(result))
+	}
+	// result is still valid after the input is destroyed
+	g.Destroy(multi)
+	if g.NumGeoms(result) != 2 {
+		t.Error("unexpected result", g.AsWkt(result))
+	}
+}
+
+func TestIsZeroArea(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	for _, tc := range []struct {
+		wkt      string
+		expected bool
+	}{
+		{"POLYGON((0 0, 5 5, 10 10, 0 0))", true},
+		{"POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))", false},
+		{"POLYGON((0.0001 0.0001, 0.0002 0.0001, 0.0002 0.0002, 0.0001 0.0001))", false},
+		{"POLYGON((1e6 1e6, 2e6 2e6, 3e6 3e6, 1e6 1e6))", true},
+	} {
+		if result := g.IsZeroArea(g.FromWkt(tc.wkt)); result != tc.expected {
+			t.Errorf("unexpected result for %s: %v", tc.wkt, result)
+		}
+	}
+}
+
+func TestSetPrecision(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	geom := g.FromWkt("POLYGON((0.123456789 0.123456789, 10.123456789 0.123456789, 10.123456789 10.987654321, 0.123456789 0.123456789))")
+	reduced, err := g.SetPrecision(geom, 1e-2)
+	if !Caps().SetPrecision {
+		if err == nil {
+			t.Fatal("expected unsupported error")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.IsValid(reduced) {
+		t.Error("reduced geometry is invalid", g.AsWkt(reduced))
+	}
+	coords, err := g.Coords(g.ExteriorRing(reduced))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range coords {
+		for _, v := range c {
+			if math.Abs(v*100-math.Round(v*100)) > 1e-6 {
+				t.Error("coordinate not rounded", v)
+			}
+		}
+	}
+	if decoded := g.FromWkb(g.AsWkb(reduced)); !g.Equals(decoded, reduced) {
+		t.Error("rounded coordinates changed in WKB", g.AsWkt(decoded))
+	}
+}
+
+func TestManualDestroy(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	g.SetManualDestroy(true)
+	geom := g.FromWkt("POINT(1 2)")
+	g.DestroyLater(geom)
+	// no finalizer registered, SetFinalizer(nil) in Destroy is a no-op
+	g.Destroy(geom)
+	if geom.v != nil {
+		t.Error("geometry not destroyed")
+	}
+}
+
+func benchmarkBuildDestroy(b *testing.B, manual bool) {
+	g := NewGeos()
+	defer g.Finish()
+	g.SetManualDestroy(manual)
+
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	pauseStart := stats.PauseTotalNs
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 100000; j++ {
+			geom := g.FromWkt("POINT(1 2)")
+			g.DestroyLater(geom)
+			g.Destroy(geom)
+		}
+		runtime.GC()
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&stats)
+	b.ReportMetric(float64(stats.PauseTotalNs-pauseStart)/float64(b.N), "gc-pause-ns/op")
+}
+
+func BenchmarkBuildDestroyFinalizer(b *testing.B) { benchmarkBuildDestroy(b, false) }
+func BenchmarkBuildDestroyManual(b *testing.B)    { benchmarkBuildDestroy(b, true) }
+
+func TestCheckedPredicates(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	a := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+	b := g.FromWkt("POINT(5 5)")
+
+	// both without SRID
+	if ok, err := g.CheckedContains(a, b); err != nil || !ok {
+		t.Error("unexpected result", ok, err)
+	}
+
+	g.SetSRID(a, 4326)
+	g.SetSRID(b, 3857)
+	if g.SRID(a) != 4326 || g.SRID(b) != 3857 {
+		t.Fatal("SRID not set", g.SRID(a), g.SRID(b))
+	}
+	if _, err := g.CheckedContains(a, b); err == nil {
+		t.Error("different SRIDs not rejected")
+	}
+	if _, err := g.CheckedIntersects(a, b); err == nil {
+		t.Error("different SRIDs not rejected")
+	}
+
+	g.SetSRID(b, 4326)
+	if ok, err := g.CheckedIntersects(a, b); err != nil || !ok {
+		t.Error("unexpected result", ok, err)
+	}
+}
+
+func TestCreateCoordSeqFromBuffer(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	cs, err := g.CreateCoordSeqFromBuffer([]float64{0, 0, 10, 0, 10, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	geom, err := cs.AsLineString(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Destroy(geom)
+	coords, err := g.Coords(geom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(coords) != 3 || coords[1] != [2]float64{10, 0} || coords[2] != [2]float64{10, 5} {
+		t.Error("unexpected coords", coords)
+	}
+
+	if _, err := g.CreateCoordSeqFromBuffer([]float64{0, 0, 10}); err == nil {
+		t.Error("odd number of values not rejected")
+	}
+}
+
+func TestSimplifyKeepEndpoints(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	line := g.FromWkt("LINESTRING(0 0, 5 0.1, 10 0, 10 10, 0 10, 0 0.2)")
+	defer g.Destroy(line)
+	simplified := g.SimplifyKeepEndpoints(line, 1)
+	if simplified == nil {
+		t.Fatal("unable to simplify")
+	}
+	defer g.Destroy(simplified)
+	coords, err := g.Coords(simplified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(coords) != 5 {
+		t.Error("line not simplified", coords)
+	}
+	if coords[0] != [2]float64{0, 0} || coords[len(coords)-1] != [2]float64{0, 0.2} {
+		t.Error("endpoints not kept", coords)
+	}
+
+	// GEOS may drop endpoints of closed lines
+	restored, changed := restoreEndpoints(
+		[][2]float64{{0, 0}, {5, 0}, {10, 0}, {0, 0}},
+		[][2]float64{{10, 0}, {0, 5}},
+	)
+	if !changed {
+		t.Error("endpoints not restored")
+	}
+	if !reflect.DeepEqual(restored, [][2]float64{{0, 0}, {10, 0}, {0, 5}, {0, 0}}) {
+		t.Error("unexpected coords", restored)
+	}
+
+	if g.SimplifyKeepEndpoints(g.FromWkt("POINT(0 0)"), 1) != nil {
+		t.Error("point not rejected")
+	}
+}
+
+func TestToMulti(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	poly := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+	multi := g.ToMulti(poly)
+	if g.TypeID(multi) != MultiPolygonTypeID || g.NumGeoms(multi) != 1 {
+		t.Fatal("unexpected geometry", g.AsWkt(multi))
+	}
+	if !g.Equals(g.Geoms(multi)[0], poly) {
+		t.Error("part not equal to polygon", g.AsWkt(multi))
+	}
+	clone := g.ToMulti(multi)
+	if clone == multi || !g.Equals(clone, multi) {
+		t.Error("MultiPolygon not returned as new geometry", g.AsWkt(clone))
+	}
+	// result is still valid after the input is destroyed
+	g.Destroy(multi)
+	if g.NumGeoms(clone) != 1 {
+		t.Error("unexpected geometry", g.AsWkt(clone))
+	}
+	if g.ToMulti(nil) != nil {
+		t.Error("nil geometry not rejected")
+	}
+
+	line := g.ToMulti(g.FromWkt("LINESTRING(0 0, 10 0)"))
+	if g.TypeID(line) != MultiLineStringTypeID {
+		t.Error("unexpected geometry", g.AsWkt(line))
+	}
+	point := g.ToMulti(g.FromWkt("POINT(0 0)"))
+	if g.TypeID(point) != MultiPointTypeID {
+		t.Error("unexpected geometry", g.AsWkt(point))
+	}
+}
+
+func TestSplitPolygon(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	square := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+	line := g.FromWkt("LINESTRING(-5 5, 15 5)")
+	parts := g.SplitPolygon(square, line)
+	if len(parts) != 2 {
+		t.Fatal("unexpected number of parts", len(parts))
+	}
+	for _, p := range parts {
+		if g.TypeID(p) != PolygonTypeID || p.Area() != 50 {
+			t.Error("unexpected part", g.AsWkt(p))
+		}
+		g.Destroy(p)
+	}
+
+	// line outside of polygon
+	parts = g.SplitPolygon(square, g.FromWkt("LINESTRING(20 0, 20 10)"))
+	if len(parts) != 1 || parts[0].Area() != 100 {
+		t.Error("unexpected parts", parts)
+	}
+}
+
+func TestLabelPoint(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	// C-shaped polygon, centroid is outside
+	poly := g.FromWkt("POLYGON((0 0, 10 0, 10 1, 1 1, 1 9, 10 9, 10 10, 0 10, 0 0))")
+	p := g.LabelPoint(poly)
+	if p == nil || !g.Intersects(poly, p) {
+		t.Fatal("label point not in polygon")
+	}
+	if surface := g.PointOnSurface(poly); !g.Equals(p, surface) {
+		t.Error("PointOnSurface not used", g.AsWkt(p))
+	}
+
+	// sliver polygon, emulate failing PointOnSurface
+	sliver := g.FromWkt("POLYGON((0 0, 10 0, 10 1e-12, 0 0))")
+	failing := func(*Geom) *Geom { return nil }
+	p = g.labelPoint(sliver, failing, g.Centroid)
+	if p == nil || !g.Intersects(sliver, p) || !g.Equals(p, g.Centroid(sliver)) {
+		t.Error("centroid not used", p)
+	}
+	// centroid outside of C-shaped polygon
+	p = g.labelPoint(poly, failing, g.Centroid)
+	if p == nil || !g.Equals(p, g.Point(0, 0)) {
+		t.Error("first vertex not used", p)
+	}
+
+	if p := g.LabelPoint(g.FromWkt("POLYGON EMPTY")); p != nil {
+		t.Error("unexpected point for empty polygon", g.AsWkt(p))
+	}
+}
+
+func TestAsEwkbHex(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+	g.SetHandleSrid(3857)
+
+	point := g.FromWkt("POINT(1000 2000)")
+	ewkb := g.AsEwkbHex(point)
+	if ewkb == nil {
+		t.Fatal("unable to encode point")
+	}
+	// little endian point with SRID flag and SRID 3857
+	if !strings.HasPrefix(string(ewkb), "0101000020110F0000") {
+		t.Error("unexpected EWKB", string(ewkb))
+	}
+
+	wkb, err := hex.DecodeString(string(ewkb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := g.FromWkb(wkb)
+	if decoded == nil {
+		t.Fatal("unable to decode EWKB")
+	}
+	if !g.Equals(decoded, point) || g.SRID(decoded) != 3857 {
+		t.Error("unexpected geometry", g.AsWkt(decoded), g.SRID(decoded))
+	}
+}
+
+func TestAsWkbByteOrder(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	point := g.FromWkt("POINT(1 2)")
+	xdr := g.AsWkbByteOrder(point, true)
+	ndr := g.AsWkbByteOrder(point, false)
+	if len(xdr) != 21 || len(ndr) != 21 {
+		t.Fatal("unexpected WKB", xdr, ndr)
+	}
+	if xdr[0] != 0 || ndr[0] != 1 {
+		t.Error("unexpected byte order flags", xdr[0], ndr[0])
+	}
+	if bytes.Equal(xdr, ndr) {
+		t.Error("WKB not different")
+	}
+	for _, wkb := range [][]byte{xdr, ndr} {
+		if decoded := g.FromWkb(wkb); decoded == nil || !g.Equals(decoded, point) {
+			t.Error("unable to decode", wkb)
+		}
+	}
+
+	if wkb := g.AsWkbByteOrder(g.FromWkt("POINT Z(1 2 3)"), true); len(wkb) != 29 {
+		t.Error("unexpected 3D WKB", wkb)
+	}
+}
+
+func TestFromWkbSRID(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	for _, test := range []struct {
+		hex  string
+		srid int
+	}{
+		// little endian EWKB, POINT(1 2) with SRID 3857
+		{"0101000020110F0000000000000000F03F0000000000000040", 3857},
+		// big endian EWKB, POINT(1 2) with SRID 4326
+		{"0020000001000010E63FF00000000000004000000000000000", 4326},
+		// WKB without SRID
+		{"0101000000000000000000F03F0000000000000040", 0},
+	} {
+		wkb, err := hex.DecodeString(test.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		geom := g.FromWkb(wkb)
+		if geom == nil {
+			t.Fatal("unable to decode", test.hex)
+		}
+		if !g.Equals(geom, g.FromWkt("POINT(1 2)")) {
+			t.Error("unexpected geometry", g.AsWkt(geom))
+		}
+		if srid := g.SRID(geom); srid != test.srid {
+			t.Error("unexpected SRID", test.hex, srid)
+		}
+	}
+}
+
+func TestUnionPolygonsDuplicates(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	polygons := []*Geom{
+		g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))"),
+		g.FromWkt("POLYGON((5 5, 15 5, 15 15, 5 15, 5 5))"),
+		g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))"),
+	}
+	if unique := g.removeDuplicates(append([]*Geom{}, polygons...)); len(unique) != 2 || unique[0] != polygons[0] || unique[1] != polygons[1] {
+		t.Fatal("duplicate not removed", unique)
+	}
+
+	union := g.UnionPolygons([]*Geom{
+		g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))"),
+		g.FromWkt("POLYGON((5 5, 15 5, 15 15, 5 15, 5 5))"),
+		g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))"),
+	})
+	if union == nil || g.TypeID(union) != PolygonTypeID || union.Area() != 175 {
+		t.Error("unexpected union", union)
+	}
+}
+
+// settledLiveGeomCount runs the GC until all finalizers are done.
+func settledLiveGeomCount() int64 {
+	count := LiveGeomCount()
+	for i := 0; i < 100; i++ {
+		runtime.GC()
+		time.Sleep(5 * time.Millisecond)
+		c := LiveGeomCount()
+		if c == count && i > 1 {
+			break
+		}
+		count = c
+	}
+	return count
+}
+
+func TestLiveGeomCount(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	baseline := settledLiveGeomCount()
+
+	points := make([]*Geom, 100)
+	for i := range points {
+		points[i] = g.Point(float64(i), 0)
+	}
+	if c := LiveGeomCount(); c != baseline+100 {
+		t.Error("unexpected count after creating points", c-baseline)
+	}
+	// points are owned by multipoint
+	multi := g.MultiPoint(points)
+	if c := LiveGeomCount(); c != baseline+1 {
+		t.Error("unexpected count after creating multipoint", c-baseline)
+	}
+	g.Destroy(multi)
+
+	for i := 0; i < 100; i++ {
+		cs, err := g.CreateCoordSeq(5, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.DestroyCoordSeq(cs)
+		poly := g.BoundsPolygon(MakeBounds(0, 0, float64(i+1), 1))
+		// destroyed by finalizer
+		g.DestroyLater(poly)
+	}
+	if c := LiveGeomCount(); c != baseline+100 {
+		t.Error("unexpected count after creating polygons", c-baseline)
+	}
+	if c := settledLiveGeomCount(); c != baseline {
+		t.Error("count not back to baseline", c-baseline)
+	}
+}
+
+func TestCascadedUnion(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	squares := make([]*Geom, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		x, y := float64(i%40), float64(i/40)
+		squares = append(squares, g.BoundsPolygon(MakeBounds(x, y, x+1, y+1)))
+	}
+	union := g.CascadedUnion(squares, 64)
+	if union == nil {
+		t.Fatal("no union")
+	}
+	if g.TypeID(union) != PolygonTypeID || math.Abs(union.Area()-1000) > 1e-9 {
+		t.Error("unexpected union", g.Type(union), union.Area())
+	}
+
+	// disjoint squares are not merged
+	squares = squares[:0]
+	for i := 0; i < 100; i++ {
+		x := float64(i * 2)
+		squares = append(squares, g.BoundsPolygon(MakeBounds(x, 0, x+1, 1)))
+	}
+	union = g.CascadedUnion(squares, 8)
+	if union == nil {
+		t.Fatal("no union")
+	}
+	if g.NumGeoms(union) != 100 || math.Abs(union.Area()-100) > 1e-9 {
+		t.Error("unexpected union", g.NumGeoms(union), union.Area())
+	}
+
+	// LineString in the second batch fails the union
+	squares = squares[:0]
+	for i := 0; i < 12; i++ {
+		x := float64(i)
+		if i == 5 {
+			squares = append(squares, g.FromWkt("LINESTRING(5 0, 6 1)"))
+			continue
+		}
+		squares = append(squares, g.BoundsPolygon(MakeBounds(x, 0, x+1, 1)))
+	}
+	baseline := settledLiveGeomCount()
+	if union := g.CascadedUnion(squares, 4); union != nil {
+		t.Fatal("union with LineString not rejected", g.AsWkt(union))
+	}
+	// inputs and intermediate unions are destroyed
+	if c := LiveGeomCount(); c != baseline-12 {
+		t.Error("geometries not destroyed", c-baseline+12)
+	}
+}
+
+func TestIsValidReason(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	if r := g.IsValidReason(g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")); r != "Valid Geometry" {
+		t.Error("unexpected reason", r)
+	}
+	if r := g.IsValidReason(g.FromWkt("POLYGON((0 0, 10 10, 10 0, 0 10, 0 0))")); r != "Self-intersection[5 5]" {
+		t.Error("unexpected reason", r)
+	}
+}
+
+func TestSimplifyToVertexCount(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	coords := make([]string, 1000)
+	for i := range coords {
+		coords[i] = fmt.Sprintf("%d %f", i, 100*math.Sin(float64(i)/20))
+	}
+	line := g.FromWkt("LINESTRING(" + strings.Join(coords, ",") + ")")
+
+	for _, test := range []struct{ max, min int }{{500, 250}, {100, 50}, {10, 2}} {
+		result := g.SimplifyToVertexCount(line, test.max)
+		if result == nil {
+			t.Fatal("unable to simplify")
+		}
+		if n := int(g.NumCoordinates(result)); n > test.max || n < test.min {
+			t.Error("unexpected number of vertices", test.max, n)
+		}
+	}
+
+	result := g.SimplifyToVertexCount(line, 1000)
+	if result == line || !g.Equals(result, line) {
+		t.Error("expected clone of line")
+	}
+}
+
+func TestAsTwkb(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	for _, test := range []struct {
+		wkt       string
+		precision int
+		expected  string
+	}{
+		// example from PostGIS ST_AsTWKB
+		{"LINESTRING(1 1, 5 5)", 0, "02000202020808"},
+		{"POINT(1.5 -2.25)", 2, "4100ac02c103"},
+		{"POINT EMPTY", 0, "0110"},
+		{"POLYGON((0 0, 2 0, 2 2, 0 0))", 0, "030001040000040000040303"},
+		{"MULTIPOINT(1 1, 2 2)", 0, "04000202020202"},
+		{"GEOMETRYCOLLECTION(POINT(1 1), POINT(2 2))", 0, "0700020100020201000404"},
+	} {
+		twkb, err := g.AsTwkb(g.FromWkt(test.wkt), test.precision)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := hex.EncodeToString(twkb); h != test.expected {
+			t.Errorf("%s: %s != %s", test.wkt, h, test.expected)
+		}
+	}
+
+	if _, err := g.AsTwkb(g.FromWkt("POINT(0 0)"), 8); err == nil {
+		t.Error("invalid precision not rejected")
+	}
+
+	coords := make([]string, 1000)
+	for i := range coords {
+		coords[i] = fmt.Sprintf("%d %d", 1000000+i*10, 6000000+i*5)
+	}
+	line := g.FromWkt("LINESTRING(" + strings.Join(coords, ",") + ")")
+	twkb, err := g.AsTwkb(line, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wkb := g.AsWkb(line); len(twkb)*5 > len(wkb) {
+		t.Error("TWKB not substantially smaller than WKB", len(twkb), len(wkb))
+	}
+}
+
+func TestDissolveAdjacent(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	polygons := []*Geom{
+		g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))"),
+		g.FromWkt("POLYGON((50 0, 60 0, 60 10, 50 10, 50 0))"),
+		// shares the boundary with the first polygon
+		g.FromWkt("POLYGON((10 0, 20 0, 20 10, 10 10, 10 0))"),
+	}
+	result := g.DissolveAdjacent(polygons)
+	if len(result) != 2 {
+		t.Fatal("unexpected result", result)
+	}
+	if !g.Equals(result[0], g.FromWkt("POLYGON((0 0, 20 0, 20 10, 0 10, 0 0))")) {
+		t.Error("unexpected dissolved polygon", g.AsWkt(result[0]))
+	}
+	if !g.Equals(result[1], polygons[1]) {
+		t.Error("unexpected separate polygon", g.AsWkt(result[1]))
+	}
+	if polygons[0].Area() != 100 {
+		t.Error("input polygon modified")
+	}
+
+	// connected through the middle polygon
+	chain := g.DissolveAdjacent([]*Geom{
+		g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))"),
+		g.FromWkt("POLYGON((20 0, 30 0, 30 10, 20 10, 20 0))"),
+		g.FromWkt("POLYGON((5 5, 25 5, 25 8, 5 8, 5 5))"),
+	})
+	if len(chain) != 1 || g.Type(chain[0]) != "Polygon" {
+		t.Error("unexpected result", chain)
+	}
+}
+
+func TestSimplifyLevels(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	coords := make([]string, 1000)
+	for i := range coords {
+		coords[i] = fmt.Sprintf("%d %f", i, 100*math.Sin(float64(i)/20))
+	}
+	line := g.FromWkt("LINESTRING(" + strings.Join(coords, ",") + ")")
+
+	tolerances := []float64{1, 10, 0.1}
+	levels := g.SimplifyLevels(line, tolerances)
+	if len(levels) != 3 {
+		t.Fatal("unexpected levels", levels)
+	}
+	fine := g.NumCoordinates(levels[2])
+	medium := g.NumCoordinates(levels[0])
+	coarse := g.NumCoordinates(levels[1])
+	if !(1000 > fine && fine > medium && medium > coarse) {
+		t.Error("vertices not decreasing with tolerance", fine, medium, coarse)
+	}
+	for i, level := range levels {
+		if dist, _ := g.HausdorffDistance(line, level); dist > 2*tolerances[i] {
+			t.Error("level too far from original", tolerances[i], dist)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	coords := make([]string, 0, 501)
+	for i := 0; i < 500; i++ {
+		a := 2 * math.Pi * float64(i) / 500
+		r := 100 + 20*math.Sin(a*37)
+		coords = append(coords, fmt.Sprintf("%f %f", r*math.Cos(a), r*math.Sin(a)))
+	}
+	coords = append(coords, coords[0])
+	poly := g.FromWkt("POLYGON((" + strings.Join(coords, ",") + "))")
+	orig := poly.Bounds()
+
+	for _, target := range []int{3, 8, 20, 100} {
+		preview := g.Preview(poly, target)
+		if preview == nil {
+			t.Fatal("no preview for", target)
+		}
+		if target >= 4 {
+			if n := g.NumCoordinates(preview); int(n) > target {
+				t.Error("too many vertices", target, n)
+			}
+		}
+		b := preview.Bounds()
+		tol := 0.3 * (orig.MaxX - orig.MinX)
+		if math.Abs(b.MinX-orig.MinX) > tol || math.Abs(b.MaxX-orig.MaxX) > tol ||
+			math.Abs(b.MinY-orig.MinY) > tol || math.Abs(b.MaxY-orig.MaxY) > tol {
+			t.Error("preview does not cover original bounds", target, b, orig)
+		}
+		g.Destroy(preview)
+	}
+}
+
+func TestSimplifyDropSmall(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	geom := g.FromWkt(`MULTIPOLYGON(
+		((0 0, 50 0.1, 100 0, 100 100, 0 100, 0 0), (10 10, 10 11, 11 11, 11 10, 10 10), (20 20, 20 40, 40 40, 40 20, 20 20)),
+		((200 200, 202 200, 202 202, 200 202, 200 200)))`)
+	result := g.SimplifyDropSmall(geom, 0.5, 10)
+	if result == nil {
+		t.Fatal("no result")
+	}
+	if g.TypeID(result) != PolygonTypeID {
+		t.Fatal("island not dropped", g.AsWkt(result))
+	}
+	if n := len(g.InteriorRings(result)); n != 1 {
+		t.Error("small hole not dropped", g.AsWkt(result))
+	}
+	if math.Abs(result.Area()-(10000-400)) > 1e-6 {
+		t.Error("unexpected area", result.Area())
+	}
+	if n := g.NumCoordinates(result); n != 10 {
+		t.Error("not simplified", g.AsWkt(result))
+	}
+	g.Destroy(result)
+
+	if result := g.SimplifyDropSmall(geom, 0.5, 1e6); result != nil {
+		t.Error("expected nil", g.AsWkt(result))
+	}
+}
+
+func TestNodeLines(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	noded := g.NodeLines([]*Geom{
+		g.FromWkt("LINESTRING(0 0, 10 10)"),
+		g.FromWkt("LINESTRING(0 10, 10 0)"),
+	})
+	if noded == nil {
+		t.Fatal("no result")
+	}
+	defer g.Destroy(noded)
+
+	segments := g.Geoms(noded)
+	if len(segments) != 4 {
+		t.Fatal("expected four segments", g.AsWkt(noded))
+	}
+	for _, segment := range segments {
+		coords, err := g.Coords(segment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(coords) != 2 || (coords[0] != [2]float64{5, 5} && coords[1] != [2]float64{5, 5}) {
+			t.Error("segment does not end at crossing", g.AsWkt(segment))
+		}
+	}
+}
+
+func TestClean(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	// square with a thin spike to the right and a thin crack from the top
+	geom := g.FromWkt(`POLYGON((0 0, 10 0, 10 5, 20 5.0001, 10 5.0002, 10 10,
+		5.0002 10, 5.0001 4, 5 10, 0 10, 0 0))`)
+	defer g.Destroy(geom)
+
+	cleaned := g.Clean(geom, 0.01)
+	if cleaned == nil {
+		t.Fatal("no result")
+	}
+	defer g.Destroy(cleaned)
+
+	b := cleaned.Bounds()
+	if b.MaxX > 10.01 {
+		t.Error("spike not removed", g.AsWkt(cleaned))
+	}
+	crack := g.Point(5.0001, 8)
+	defer g.Destroy(crack)
+	if g.Contains(geom, crack) || !g.Contains(cleaned, crack) {
+		t.Error("crack not removed", g.AsWkt(cleaned))
+	}
+	if math.Abs(cleaned.Area()-100) > 0.01 {
+		t.Error("main shape not preserved", cleaned.Area())
+	}
+	square := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+	defer g.Destroy(square)
+	if dist, _ := g.HausdorffDistance(square, cleaned); dist > 0.01 {
+		t.Error("main shape not preserved", dist, g.AsWkt(cleaned))
+	}
+}
+
+func TestPolygonCoords(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	geom := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0), (2 2, 2 4, 4 4, 4 2, 2 2))")
+	defer g.Destroy(geom)
+	polygons, err := g.PolygonCoords(geom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(polygons) != 1 || len(polygons[0]) != 2 {
+		t.Fatal("unexpected rings", polygons)
+	}
+	if len(polygons[0][0]) != 5 || polygons[0][0][2] != [2]float64{10, 10} {
+		t.Error("unexpected exterior ring", polygons[0][0])
+	}
+	if len(polygons[0][1]) != 5 || polygons[0][1][2] != [2]float64{4, 4} {
+		t.Error("unexpected interior ring", polygons[0][1])
+	}
+
+	multi := g.FromWkt("MULTIPOLYGON(((0 0, 1 0, 1 1, 0 0)), ((5 5, 6 5, 6 6, 5 5)))")
+	defer g.Destroy(multi)
+	polygons, err = g.PolygonCoords(multi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(polygons) != 2 || len(polygons[0]) != 1 || len(polygons[1]) != 1 {
+		t.Error("unexpected rings", polygons)
+	}
+
+	line := g.FromWkt("LINESTRING(0 0, 1 1)")
+	defer g.Destroy(line)
+	if _, err := g.PolygonCoords(line); err == nil {
+		t.Error("linestring not rejected")
+	}
+}
+
+func TestGeomHash(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	a := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+	defer g.Destroy(a)
+	// same ring, different start vertex and orientation
+	b := g.FromWkt("POLYGON((10 10, 10 0, 0 0, 0 10, 10 10))")
+	defer g.Destroy(b)
+	// moved vertex
+	c := g.FromWkt("POLYGON((0 0, 10 0, 10 11, 0 10, 0 0))")
+	defer g.Destroy(c)
+
+	if g.GeomHash(a) == 0 {
+		t.Fatal("no hash")
+	}
+	if g.GeomHash(a) != g.GeomHash(b) {
+		t.Error("hash differs for same shape", g.GeomHash(a), g.GeomHash(b))
+	}
+	if g.GeomHash(a) == g.GeomHash(c) {
+		t.Error("hash not changed for moved vertex")
+	}
+	if coords, _ := g.Coords(g.ExteriorRing(b)); coords[0] != [2]float64{10, 10} {
+		t.Error("input geometry modified", coords)
+	}
+}
+
+func TestLinearRing(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	ring, err := g.LinearRing([][2]float64{{0, 0}, {10, 0}, {10, 10}, {0, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Type(ring) != "LinearRing" || !g.IsValid(ring) || g.NumCoordinates(ring) != 4 {
+		t.Error("unexpected ring", g.AsWkt(ring))
+	}
+	g.Destroy(ring)
+
+	// open rings are closed
+	open := [][2]float64{{0, 0}, {10, 0}, {10, 10}}
+	ring, err = g.LinearRing(open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coords, _ := g.Coords(ring); len(coords) != 4 || coords[3] != [2]float64{0, 0} {
+		t.Error("ring not closed", coords)
+	}
+	if len(open) != 3 {
+		t.Error("input modified", open)
+	}
+	g.Destroy(ring)
+
+	for _, coords := range [][][2]float64{
+		nil,
+		{{0, 0}, {10, 0}},
+		{{0, 0}, {10, 0}, {0, 0}},
+	} {
+		_, err := g.LinearRing(coords)
+		if err == nil || !strings.Contains(err.Error(), "needs at least 4") {
+			t.Error("invalid ring not rejected", coords, err)
+		}
+		if _, ok := err.(CreateError); !ok {
+			t.Errorf("expected CreateError, got %T", err)
+		}
+	}
+}
+
+func TestLineLabelPoint(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	line := g.FromWkt("LINESTRING(0 0, 10 10)")
+	defer g.Destroy(line)
+	point, angle := g.LineLabelPoint(line, 0.5)
+	if point == nil {
+		t.Fatal("no point")
+	}
+	if coords, _ := g.Coords(point); math.Abs(coords[0][0]-5) > 1e-9 || math.Abs(coords[0][1]-5) > 1e-9 {
+		t.Error("unexpected point", coords)
+	}
+	if math.Abs(angle-45) > 1e-9 {
+		t.Error("unexpected angle", angle)
+	}
+	g.Destroy(point)
+
+	bent := g.FromWkt("LINESTRING(0 0, 10 0, 10 -30)")
+	defer g.Destroy(bent)
+	for _, test := range []struct {
+		fraction float64
+		x, y     float64
+		angle    float64
+	}{
+		{0, 0, 0, 0},
+		{0.2, 8, 0, 0},
+		{0.5, 10, -10, -90},
+		{1, 10, -30, -90},
+	} {
+		point, angle := g.LineLabelPoint(bent, test.fraction)
+		if point == nil {
+			t.Fatal("no point for", test.fraction)
+		}
+		if coords, _ := g.Coords(point); math.Abs(coords[0][0]-test.x) > 1e-9 || math.Abs(coords[0][1]-test.y) > 1e-9 {
+			t.Error("unexpected point", test.fraction, coords)
+		}
+		if math.Abs(angle-test.angle) > 1e-9 {
+			t.Error("unexpected angle", test.fraction, angle)
+		}
+		g.Destroy(point)
+	}
+
+	polygon := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 0))")
+	defer g.Destroy(polygon)
+	if point, _ := g.LineLabelPoint(polygon, 0.5); point != nil {
+		t.Error("polygon not rejected")
+	}
+}
+
+func TestConcatLines(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	a := g.FromWkt("LINESTRING(0 0, 10 0)")
+	defer g.Destroy(a)
+	b := g.FromWkt("LINESTRING(10 0, 10 10, 0 10)")
+	defer g.Destroy(b)
+
+	line, err := g.ConcatLines([]*Geom{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Destroy(line)
+	coords, err := g.Coords(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][2]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	if !reflect.DeepEqual(coords, expected) {
+		t.Error("unexpected coords", coords)
+	}
+
+	polygon := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 0))")
+	defer g.Destroy(polygon)
+	if _, err := g.ConcatLines([]*Geom{a, polygon}); err == nil {
+		t.Error("polygon not rejected")
+	}
+	if _, err := g.ConcatLines(nil); err == nil {
+		t.Error("empty lines not rejected")
+	}
+}
+
+func TestIsAdjacent(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	a := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+	defer g.Destroy(a)
+	for _, test := range []struct {
+		wkt      string
+		adjacent bool
+	}{
+		// shared edge
+		{"POLYGON((10 0, 20 0, 20 10, 10 10, 10 0))", true},
+		// partly shared edge
+		{"POLYGON((10 5, 20 5, 20 15, 10 15, 10 5))", true},
+		// overlapping
+		{"POLYGON((5 0, 15 0, 15 10, 5 10, 5 0))", false},
+		// touching corner
+		{"POLYGON((10 10, 20 10, 20 20, 10 20, 10 10))", false},
+		// disjoint
+		{"POLYGON((20 0, 30 0, 30 10, 20 10, 20 0))", false},
+	} {
+		b := g.FromWkt(test.wkt)
+		if adjacent := g.IsAdjacent(a, b); adjacent != test.adjacent {
+			t.Errorf("%s: %v != %v", test.wkt, adjacent, test.adjacent)
+		}
+		g.Destroy(b)
+	}
+}
+
+func TestBufferCache(t *testing.T) {
+	g := NewGeos()
+	baseline := settledLiveGeomCount()
+
+	g.EnableBufferCache(2)
+	point := g.FromWkt("POINT(0 0)")
+
+	first := g.Buffer(point, 10)
+	second := g.Buffer(point, 10)
+	if first == nil || second == nil {
+		t.Fatal("no buffer")
+	}
+	if g.bufferCache.misses != 1 || g.bufferCache.hits != 1 {
+		t.Error("second buffer not cached", g.bufferCache.misses, g.bufferCache.hits)
+	}
+	if first == second || !g.Equals(first, second) {
+		t.Error("expected equal clones")
+	}
+	g.Destroy(first)
+	g.Destroy(second)
+
+	// other distance is not cached
+	other := g.Buffer(point, 20)
+	if g.bufferCache.misses != 2 {
+		t.Error("different distance returned from cache")
+	}
+	g.Destroy(other)
+
+	// oldest entry is evicted
+	line := g.FromWkt("LINESTRING(0 0, 10 10)")
+	g.Destroy(g.Buffer(line, 10))
+	if g.bufferCache.lru.Len() != 2 {
+		t.Error("unexpected cache size", g.bufferCache.lru.Len())
+	}
+	g.Destroy(g.Buffer(point, 10))
+	if g.bufferCache.misses != 4 {
+		t.Error("oldest entry not evicted", g.bufferCache.misses)
+	}
+
+	// entries with the same hash but another source geometry are not used
+	key := bufferKey{hash: g.GeomHash(point), size: 10}
+	entry := g.bufferCache.entries[key].Value.(*bufferEntry)
+	g.Destroy(entry.source)
+	entry.source = g.FromWkt("POINT(100 100)")
+	collision := g.Buffer(point, 10)
+	if g.bufferCache.misses != 5 {
+		t.Error("hash collision returned from cache", g.bufferCache.misses)
+	}
+	if !g.Intersects(collision, point) {
+		t.Error("unexpected buffer", g.AsWkt(collision))
+	}
+	g.Destroy(collision)
+	if g.bufferCache.lru.Len() != 2 {
+		t.Error("unexpected cache size", g.bufferCache.lru.Len())
+	}
+
+	g.Destroy(point)
+	g.Destroy(line)
+	g.Finish()
+	if c := settledLiveGeomCount(); c != baseline {
+		t.Error("cached geometries not destroyed", c-baseline)
+	}
+}
+
+func TestOverlapFraction(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	a := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+	defer g.Destroy(a)
+	for _, test := range []struct {
+		wkt      string
+		fraction float64
+	}{
+		{"POLYGON((5 -5, 20 -5, 20 20, 5 20, 5 -5))", 0.5},
+		{"POLYGON((-5 -5, 20 -5, 20 20, -5 20, -5 -5))", 1},
+		{"POLYGON((20 0, 30 0, 30 10, 20 10, 20 0))", 0},
+		{"POLYGON((10 0, 20 0, 20 10, 10 10, 10 0))", 0},
+	} {
+		b := g.FromWkt(test.wkt)
+		if f := g.OverlapFraction(a, b); math.Abs(f-test.fraction) > 1e-9 {
+			t.Errorf("%s: %f != %f", test.wkt, f, test.fraction)
+		}
+		g.Destroy(b)
+	}
+
+	line := g.FromWkt("LINESTRING(0 0, 10 10)")
+	defer g.Destroy(line)
+	if f := g.OverlapFraction(line, a); f != 0 {
+		t.Error("unexpected fraction for line", f)
+	}
+}
+
+func TestForceShapefileOrientation(t *testing.T) {
+	g := NewGeos()
+	defer g.Finish()
+
+	// counter-clockwise exterior, clockwise hole
+	geom := g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0), (2 2, 2 4, 4 4, 4 2, 2 2))")
+	if g.SignedArea(g.ExteriorRing(geom)) < 0 || g.SignedArea(g.InteriorRings(geom)[0]) > 0 {
+		t.Fatal("unexpected input orientation")
+	}
+	result := g.ForceShapefileOrientation(geom)
+	if result == nil {
+		t.Fatal("no result")
+	}
+	defer g.Destroy(result)
+	if a := g.SignedArea(g.ExteriorRing(result)); a != -100 {
+		t.Error("exterior not clockwise", a)
+	}
+	holes := g.InteriorRings(result)
+	if len(holes) != 1 {
+		t.Fatal("unexpected holes", g.AsWkt(result))
+	}
+	if a := g.SignedArea(holes[0]); a != 4 {
+		t.Error("hole not counter-clockwise", a)
+	}
+	if !g.Equals(geom, result) {
+		t.Error("geometry changed", g.AsWkt(result))
+	}
+}
@@ -14,6 +14,7 @@ extern void IndexAdd(GEOSContextHandle_t, GEOSSTRtree *, const GEOSGeometry *, s
 */
 import "C"
 import (
+	"fmt"
 	"sync"
 	"unsafe"
 )
@@ -27,14 +28,73 @@ type Index struct {
 	v     *C.GEOSSTRtree
 	mu    *sync.Mutex
 	geoms []IndexGeom
+	// ownsGeoms is true if the geometries are clones that are destroyed
+	// with the index (e.g. for IndexSnapshot)
+	ownsGeoms bool
+	// prepared geometries, created on demand by IndexContainingPolygons
+	prepared []*indexPrepared
+}
+
+// indexPrepared is the prepared geometry of an indexed geometry.
+// Prepared geometries are not thread safe, mu protects each one
+// without blocking the whole index.
+type indexPrepared struct {
+	mu   sync.Mutex
+	prep *PreparedGeom
+}
+
+// IndexResult is a geometry returned by IndexContainingPolygons.
+// Index is the position of the geometry in the index, as returned by
+// IndexQuery.
+type IndexResult struct {
+	Index int
+	Geom  *Geom
 }
 
 func (g *Geos) CreateIndex() *Index {
-	tree := C.GEOSSTRtree_create_r(g.v, 10)
+	index, err := g.CreateIndexWithCapacity(10)
+	if err != nil {
+		panic(err)
+	}
+	return index
+}
+
+// CreateIndexWithCapacity creates an Index with the given STRtree
+// node capacity. nodeCapacity needs to be at least 2.
+func (g *Geos) CreateIndexWithCapacity(nodeCapacity int) (*Index, error) {
+	if nodeCapacity < 2 {
+		return nil, CreateError(fmt.Sprintf("could not create Index, STRtree node capacity needs to be at least 2, got %d", nodeCapacity))
+	}
+	tree := C.GEOSSTRtree_create_r(g.v, C.size_t(nodeCapacity))
 	if tree == nil {
-		panic("unable to create tree")
+		return nil, CreateError("unable to create tree")
+	}
+	return &Index{v: tree, mu: &sync.Mutex{}, geoms: []IndexGeom{}}, nil
+}
+
+// DestroyIndex frees the STRtree of index and all prepared geometries
+// created by IndexContainingPolygons. The geometries of the index are
+// not destroyed, except for the clones of an IndexSnapshot.
+func (g *Geos) DestroyIndex(index *Index) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	if index.v != nil {
+		C.GEOSSTRtree_destroy_r(g.v, index.v)
+		index.v = nil
+	}
+	for _, p := range index.prepared {
+		if p != nil && p.prep != nil {
+			g.PreparedDestroy(p.prep)
+			p.prep = nil
+		}
+	}
+	index.prepared = nil
+	if index.ownsGeoms {
+		for _, geom := range index.geoms {
+			g.Destroy(geom.Geom)
+		}
+		index.geoms = nil
 	}
-	return &Index{tree, &sync.Mutex{}, []IndexGeom{}}
 }
 
 // IndexAdd adds a geom to the index with the id.
@@ -43,7 +103,48 @@ func (g *Geos) IndexAdd(index *Index, geom *Geom) {
 	defer index.mu.Unlock()
 	id := len(index.geoms)
 	C.IndexAdd(g.v, index.v, geom.v, C.size_t(id))
-	index.geoms = append(index.geoms, IndexGeom{geom})
+	index.geoms = append(index.geoms, IndexGeom{Geom: geom})
+}
+
+// IndexSnapshot returns a new Index with clones of all geometries of index.
+// The snapshot can be queried while the original index is modified.
+// Geometries keep their position, so the results of IndexQuery refer
+// to the same geometries in both indices. The clones are owned by the
+// snapshot and they are destroyed with DestroyIndex. Returns nil if a
+// geometry could not be cloned.
+func (g *Geos) IndexSnapshot(index *Index) *Index {
+	index.mu.Lock()
+	geoms := make([]*Geom, len(index.geoms))
+	for i, geom := range index.geoms {
+		geoms[i] = g.Clone(geom.Geom)
+		if geoms[i] == nil {
+			index.mu.Unlock()
+			for _, clone := range geoms[:i] {
+				g.Destroy(clone)
+			}
+			return nil
+		}
+	}
+	index.mu.Unlock()
+
+	snapshot := g.CreateIndex()
+	snapshot.ownsGeoms = true
+	for _, geom := range geoms {
+		g.IndexAdd(snapshot, geom)
+	}
+	return snapshot
+}
+
+// IndexBounds returns the bounds of all geometries in the index.
+// Returns NilBounds for an empty index.
+func (g *Geos) IndexBounds(index *Index) Bounds {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	bounds := NilBounds
+	for _, geom := range index.geoms {
+		bounds = bounds.Extend(geom.Geom.Bounds())
+	}
+	return bounds
 }
 
 // IndexQueryGeoms queries the index for intersections with geom.
@@ -75,3 +176,43 @@ func (g *Geos) IndexQuery(index *Index, geom *Geom) []int {
 	}
 	return indices
 }
+
+// IndexContainingPolygons queries the index for geometries that contain point
+// (e.g. for assigning nodes to administrative areas). Candidates are tested
+// with prepared geometries, which are created on first use and kept
+// in the index until DestroyIndex. Lookups can run concurrently, only
+// tests against the same prepared geometry are serialized.
+func (g *Geos) IndexContainingPolygons(index *Index, point *Geom) []IndexResult {
+	hits := g.IndexQuery(index, point)
+
+	var results []IndexResult
+	for _, idx := range hits {
+		p, geom := indexPreparedGeom(index, idx)
+		p.mu.Lock()
+		if p.prep == nil {
+			p.prep = g.Prepare(geom)
+		}
+		contains := p.prep != nil && g.PreparedContains(p.prep, point)
+		p.mu.Unlock()
+		if contains {
+			results = append(results, IndexResult{Index: idx, Geom: geom})
+		}
+	}
+	return results
+}
+
+// indexPreparedGeom returns the prepared geometry entry and the geometry
+// at idx.
+func indexPreparedGeom(index *Index, idx int) (*indexPrepared, *Geom) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	if len(index.prepared) < len(index.geoms) {
+		prepared := make([]*indexPrepared, len(index.geoms))
+		copy(prepared, index.prepared)
+		index.prepared = prepared
+	}
+	if index.prepared[idx] == nil {
+		index.prepared[idx] = &indexPrepared{}
+	}
+	return index.prepared[idx], index.geoms[idx].Geom
+}
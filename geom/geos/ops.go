@@ -4,9 +4,35 @@ package geos
 #cgo LDFLAGS: -lgeos_c
 #include "geos_c.h"
 #include <stdlib.h>
+
+// wrap optional functions to keep the package buildable with older GEOS versions
+#define GEOS_AT_LEAST(major, minor) (GEOS_VERSION_MAJOR > major || (GEOS_VERSION_MAJOR == major && GEOS_VERSION_MINOR >= minor))
+
+static GEOSGeometry *clipByRect(GEOSContextHandle_t handle, const GEOSGeometry *g, double xmin, double ymin, double xmax, double ymax) {
+#if GEOS_AT_LEAST(3, 5)
+	return GEOSClipByRect_r(handle, g, xmin, ymin, xmax, ymax);
+#else
+	return NULL;
+#endif
+}
+
+static GEOSGeometry *setPrecision(GEOSContextHandle_t handle, const GEOSGeometry *g, double gridSize) {
+#if GEOS_AT_LEAST(3, 6)
+	return GEOSGeom_setPrecision_r(handle, g, gridSize, 0);
+#else
+	return NULL;
+#endif
+}
 */
 import "C"
 
+import (
+	"fmt"
+	"math"
+	"sort"
+	"unsafe"
+)
+
 func (g *Geos) Contains(a, b *Geom) bool {
 	result := C.GEOSContains_r(g.v, a.v, b.v)
 	if result == 1 {
@@ -25,21 +51,324 @@ func (g *Geos) Intersects(a, b *Geom) bool {
 	return false
 }
 
+// RelatePattern returns true if the DE-9IM intersection matrix of a and b
+// matches the pattern (e.g. "T*F**F***" for within).
+func (g *Geos) RelatePattern(a, b *Geom, pattern string) bool {
+	cs := C.CString(pattern)
+	defer C.free(unsafe.Pointer(cs))
+	result := C.GEOSRelatePattern_r(g.v, a.v, b.v, cs)
+	if result == 1 {
+		return true
+	}
+	// result == 2 -> exception (already logged to console)
+	return false
+}
+
+// IsAdjacent returns true if a and b share a boundary segment (e.g. two
+// polygons with a common edge), but their interiors do not intersect.
+// Geometries that only touch at single points are not adjacent.
+func (g *Geos) IsAdjacent(a, b *Geom) bool {
+	// interiors do not intersect, boundaries intersect along lines
+	return g.RelatePattern(a, b, "F***1****")
+}
+
+// HausdorffDistance returns the discrete Hausdorff distance between a and b,
+// the largest distance from a point of one geometry to the other geometry.
+func (g *Geos) HausdorffDistance(a, b *Geom) (float64, error) {
+	var dist C.double
+	if C.GEOSHausdorffDistance_r(g.v, a.v, b.v, &dist) == 0 {
+		return 0, Error("unable to calculate Hausdorff distance")
+	}
+	return float64(dist), nil
+}
+
+// GeomChanged returns true if a and b differ by more than tolerance, based
+// on their HausdorffDistance. Geometries that only differ by small changes
+// (e.g. rounding or moved nodes) are reported as unchanged. Returns true if
+// the distance can not be calculated (e.g. for empty geometries).
+func (g *Geos) GeomChanged(a, b *Geom, tolerance float64) bool {
+	if g.IsEmpty(a) || g.IsEmpty(b) {
+		return g.IsEmpty(a) != g.IsEmpty(b)
+	}
+	dist, err := g.HausdorffDistance(a, b)
+	if err != nil {
+		return true
+	}
+	return dist > tolerance
+}
+
+// CheckedContains is like Contains, but it returns an error if
+// a and b have different SRIDs.
+func (g *Geos) CheckedContains(a, b *Geom) (bool, error) {
+	if err := g.checkSRID(a, b); err != nil {
+		return false, err
+	}
+	return g.Contains(a, b), nil
+}
+
+// CheckedIntersects is like Intersects, but it returns an error if
+// a and b have different SRIDs.
+func (g *Geos) CheckedIntersects(a, b *Geom) (bool, error) {
+	if err := g.checkSRID(a, b); err != nil {
+		return false, err
+	}
+	return g.Intersects(a, b), nil
+}
+
+func (g *Geos) checkSRID(a, b *Geom) error {
+	sridA, sridB := g.SRID(a), g.SRID(b)
+	if sridA != sridB {
+		return Error(fmt.Sprintf("unable to compare geometries with different SRIDs (%d and %d)", sridA, sridB))
+	}
+	return nil
+}
+
 func (g *Geos) Intersection(a, b *Geom) *Geom {
 	result := C.GEOSIntersection_r(g.v, a.v, b.v)
 	if result == nil {
 		return nil
 	}
-	geom := &Geom{result}
+	geom := newGeom(result)
 	return geom
 }
 
+// SharedBoundaryLength returns the length of the boundary that the
+// polygons a and b have in common. Returns 0 if they do not touch, or if
+// they only touch at single points.
+func (g *Geos) SharedBoundaryLength(a, b *Geom) float64 {
+	if !a.Bounds().Intersects(b.Bounds()) {
+		return 0
+	}
+	boundaryA := C.GEOSBoundary_r(g.v, a.v)
+	if boundaryA == nil {
+		return 0
+	}
+	defer C.GEOSGeom_destroy_r(g.v, boundaryA)
+	boundaryB := C.GEOSBoundary_r(g.v, b.v)
+	if boundaryB == nil {
+		return 0
+	}
+	defer C.GEOSGeom_destroy_r(g.v, boundaryB)
+
+	shared := C.GEOSIntersection_r(g.v, boundaryA, boundaryB)
+	if shared == nil {
+		return 0
+	}
+	defer C.GEOSGeom_destroy_r(g.v, shared)
+	var length C.double
+	if C.GEOSLength_r(g.v, shared, &length) == 0 {
+		return 0
+	}
+	return float64(length)
+}
+
+// OverlapFraction returns the fraction (0 to 1) of the area of a that is
+// covered by b, e.g. to weight values for spatial joins. Returns 0 if they
+// do not intersect or if a has no area.
+func (g *Geos) OverlapFraction(a, b *Geom) float64 {
+	area := a.Area()
+	if area == 0 || !a.Bounds().Intersects(b.Bounds()) {
+		return 0
+	}
+	overlap := g.Intersection(a, b)
+	if overlap == nil {
+		return 0
+	}
+	defer g.Destroy(overlap)
+	return math.Min(1, overlap.Area()/area)
+}
+
+// ClipByRect returns the part of geom inside of bounds. The result
+// is faster to compute than an Intersection with the BoundsPolygon,
+// but it is not guaranteed to be valid.
+func (g *Geos) ClipByRect(geom *Geom, bounds Bounds) (*Geom, error) {
+	if !caps.ClipByRect {
+		return nil, unsupportedError("ClipByRect")
+	}
+	result := C.clipByRect(g.v, geom.v,
+		C.double(bounds.MinX), C.double(bounds.MinY),
+		C.double(bounds.MaxX), C.double(bounds.MaxY),
+	)
+	if result == nil {
+		return nil, Error("unable to clip geometry by rect")
+	}
+	return newGeom(result), nil
+}
+
+// SetPrecision returns a copy of geom with all coordinates rounded
+// to a grid of gridSize (e.g. 0.01 for two decimal places). The result
+// is valid if geom is valid.
+func (g *Geos) SetPrecision(geom *Geom, gridSize float64) (*Geom, error) {
+	if !caps.SetPrecision {
+		return nil, unsupportedError("SetPrecision")
+	}
+	result := C.setPrecision(g.v, geom.v, C.double(gridSize))
+	if result == nil {
+		return nil, Error("unable to set precision")
+	}
+	return newGeom(result), nil
+}
+
+// PointOnSurface returns a Point that is guaranteed to be inside
+// of geom, unlike the centroid of concave polygons.
+func (g *Geos) PointOnSurface(geom *Geom) *Geom {
+	result := C.GEOSPointOnSurface_r(g.v, geom.v)
+	if result == nil {
+		return nil
+	}
+	return newGeom(result)
+}
+
+// Centroid returns the centroid Point of geom. The centroid is not always
+// inside of geom (e.g. for concave polygons), see PointOnSurface and
+// LabelPoint. Returns an empty Point if geom is empty and nil on errors.
+// The result is a new geometry owned by the caller.
+func (g *Geos) Centroid(geom *Geom) *Geom {
+	result := C.GEOSGetCentroid_r(g.v, geom.v)
+	if result == nil {
+		return nil
+	}
+	return newGeom(result)
+}
+
+// LabelPoint returns a point for label placement that is inside or on geom.
+// It returns the PointOnSurface, or the Centroid if the PointOnSurface is
+// not within geom (e.g. for degenerate polygons), or the first vertex of geom
+// as a last resort. Returns nil if geom is empty.
+func (g *Geos) LabelPoint(geom *Geom) *Geom {
+	return g.labelPoint(geom, g.PointOnSurface, g.Centroid)
+}
+
+func (g *Geos) labelPoint(geom *Geom, candidates ...func(*Geom) *Geom) *Geom {
+	for _, candidate := range candidates {
+		p := candidate(geom)
+		if p == nil {
+			continue
+		}
+		if !g.IsEmpty(p) && g.Intersects(geom, p) {
+			return p
+		}
+		g.Destroy(p)
+	}
+	return g.firstVertex(geom)
+}
+
+func (g *Geos) firstVertex(geom *Geom) *Geom {
+	switch g.TypeID(geom) {
+	case PointTypeID, LineStringTypeID, LinearRingTypeID:
+		coords, err := g.Coords(geom)
+		if err != nil || len(coords) == 0 {
+			return nil
+		}
+		return g.Point(coords[0][0], coords[0][1])
+	case PolygonTypeID:
+		ring := g.ExteriorRing(geom)
+		if ring == nil {
+			return nil
+		}
+		return g.firstVertex(ring)
+	default:
+		for _, part := range g.Geoms(geom) {
+			if p := g.firstVertex(part); p != nil {
+				return p
+			}
+		}
+		return nil
+	}
+}
+
+// LineInterpolateNormalized returns the point at the fraction (0 to 1) of
+// the length of line. Returns nil on errors.
+func (g *Geos) LineInterpolateNormalized(line *Geom, fraction float64) *Geom {
+	point := C.GEOSInterpolateNormalized_r(g.v, line.v, C.double(fraction))
+	if point == nil {
+		return nil
+	}
+	return newGeom(point)
+}
+
+// LineLabelPoint returns the point at the fraction (0 to 1) of the length
+// of the LineString line and the angle of the line at this point, e.g. for
+// the placement and rotation of road labels. The angle is in degrees,
+// counter-clockwise from the x-axis (-180 to 180). Returns nil if line is
+// not a LineString or if it is empty.
+func (g *Geos) LineLabelPoint(line *Geom, fraction float64) (*Geom, float64) {
+	if g.TypeID(line) != LineStringTypeID {
+		return nil, 0
+	}
+	coords, err := g.Coords(line)
+	if err != nil || len(coords) < 2 {
+		return nil, 0
+	}
+	fraction = math.Max(0, math.Min(1, fraction))
+	point := g.LineInterpolateNormalized(line, fraction)
+	if point == nil {
+		return nil, 0
+	}
+
+	// find the segment of the point, ignore zero length segments
+	var total float64
+	for i := 1; i < len(coords); i++ {
+		total += math.Hypot(coords[i][0]-coords[i-1][0], coords[i][1]-coords[i-1][1])
+	}
+	target := fraction * total
+	var angle, dist float64
+	for i := 1; i < len(coords); i++ {
+		dx, dy := coords[i][0]-coords[i-1][0], coords[i][1]-coords[i-1][1]
+		length := math.Hypot(dx, dy)
+		if length == 0 {
+			continue
+		}
+		angle = math.Atan2(dy, dx) * 180 / math.Pi
+		dist += length
+		if dist >= target {
+			break
+		}
+	}
+	return point, angle
+}
+
+// Buffer returns geom buffered by size. The result is cached if the
+// buffer cache is enabled (see EnableBufferCache).
 func (g *Geos) Buffer(geom *Geom, size float64) *Geom {
+	if g.bufferCache != nil {
+		return g.bufferCache.buffer(g, geom, size)
+	}
+	return g.buffer(geom, size)
+}
+
+func (g *Geos) buffer(geom *Geom, size float64) *Geom {
 	buffered := C.GEOSBuffer_r(g.v, geom.v, C.double(size), 50)
 	if buffered == nil {
 		return nil
 	}
-	return &Geom{buffered}
+	return newGeom(buffered)
+}
+
+// Clean removes slivers and narrow spikes from the (Multi)Polygon geom.
+// geom is buffered with eps and then with -eps to close cracks and gaps
+// narrower than 2*eps, and then with -eps and eps to remove spikes
+// narrower than 2*eps. The two inner buffers are combined into a single
+// -2*eps buffer. Convex corners are rounded with a radius of eps.
+// Returns the cleaned geometry if it is valid, or nil. geom is not
+// destroyed.
+func (g *Geos) Clean(geom *Geom, eps float64) *Geom {
+	result := geom
+	for _, size := range []float64{eps, -2 * eps, eps} {
+		buffered := g.Buffer(result, size)
+		if result != geom {
+			g.Destroy(result)
+		}
+		if buffered == nil {
+			return nil
+		}
+		result = buffered
+	}
+	if !g.IsValid(result) {
+		g.Destroy(result)
+		return nil
+	}
+	return result
 }
 
 func (g *Geos) SimplifyPreserveTopology(geom *Geom, tolerance float64) *Geom {
@@ -47,16 +376,464 @@ func (g *Geos) SimplifyPreserveTopology(geom *Geom, tolerance float64) *Geom {
 	if simplified == nil {
 		return nil
 	}
-	return &Geom{simplified}
+	return newGeom(simplified)
+}
+
+// SimplifyDropSmall simplifies geom with SimplifyPreserveTopology and
+// removes all polygons and interior rings with an area below minArea (e.g.
+// features that are smaller than a pixel at the target zoom level).
+// Returns nil if no polygon remains or on errors. Other geometry types are
+// only simplified.
+func (g *Geos) SimplifyDropSmall(geom *Geom, tolerance, minArea float64) *Geom {
+	simplified := g.SimplifyPreserveTopology(geom, tolerance)
+	if simplified == nil {
+		return nil
+	}
+	switch g.TypeID(simplified) {
+	case PolygonTypeID, MultiPolygonTypeID:
+	default:
+		return simplified
+	}
+	defer g.Destroy(simplified)
+
+	var polygons []*Geom
+	for _, part := range g.Geoms(simplified) {
+		if part.Area() < minArea {
+			continue
+		}
+		exterior := g.ExteriorRing(part)
+		if exterior == nil {
+			continue
+		}
+		var interiors []*Geom
+		for _, ring := range g.InteriorRings(part) {
+			if math.Abs(g.SignedArea(ring)) >= minArea {
+				// rings are owned by part
+				interiors = append(interiors, g.Clone(ring))
+			}
+		}
+		polygon := g.Polygon(g.Clone(exterior), interiors)
+		if polygon != nil {
+			polygons = append(polygons, polygon)
+		}
+	}
+	switch len(polygons) {
+	case 0:
+		return nil
+	case 1:
+		return polygons[0]
+	default:
+		return g.MultiPolygon(polygons)
+	}
+}
+
+// RingContains returns true if the ring inner is inside of the ring outer
+// (e.g. to assign holes to outer rings of multipolygons). The rings can be
+// closed LineStrings, LinearRings or Polygons (only the exterior ring is
+// used). Rings that touch the outer ring at single points or along
+// segments are still inside, as long as they do not cross it.
+func (g *Geos) RingContains(outer, inner *Geom) bool {
+	outerPoly := g.ringPolygon(outer)
+	if outerPoly == nil {
+		return false
+	}
+	defer g.Destroy(outerPoly)
+	innerPoly := g.ringPolygon(inner)
+	if innerPoly == nil {
+		return false
+	}
+	defer g.Destroy(innerPoly)
+	return g.Contains(outerPoly, innerPoly)
+}
+
+// ringPolygon returns a new Polygon for the ring, or nil on errors.
+func (g *Geos) ringPolygon(ring *Geom) *Geom {
+	if g.TypeID(ring) == PolygonTypeID {
+		if ring = g.ExteriorRing(ring); ring == nil {
+			return nil
+		}
+	}
+	coords, err := g.Coords(ring)
+	if err != nil || len(coords) == 0 || coords[0] != coords[len(coords)-1] {
+		return nil
+	}
+	linearRing, err := g.LinearRing(coords)
+	if err != nil {
+		return nil
+	}
+	// linearRing inherited by Polygon
+	polygon := g.Polygon(linearRing, nil)
+	if polygon == nil {
+		g.Destroy(linearRing)
+	}
+	return polygon
+}
+
+// ForceShapefileOrientation returns a copy of the (Multi)Polygon geom with
+// clockwise exterior rings and counter-clockwise interior rings, as required
+// by the Shapefile format. Other geometry types are only cloned.
+// Returns nil on errors.
+func (g *Geos) ForceShapefileOrientation(geom *Geom) *Geom {
+	switch g.TypeID(geom) {
+	case PolygonTypeID, MultiPolygonTypeID:
+	default:
+		return g.Clone(geom)
+	}
+
+	var polygons []*Geom
+	destroyPolygons := func() {
+		for _, p := range polygons {
+			g.Destroy(p)
+		}
+	}
+	for _, part := range g.Geoms(geom) {
+		polygon := g.orientedPolygon(part)
+		if polygon == nil {
+			destroyPolygons()
+			return nil
+		}
+		polygons = append(polygons, polygon)
+	}
+
+	result := polygons[0]
+	if g.TypeID(geom) == MultiPolygonTypeID {
+		// polygons inherited by MultiPolygon
+		if result = g.MultiPolygon(polygons); result == nil {
+			return nil
+		}
+	}
+	g.SetSRID(result, g.SRID(geom))
+	return result
+}
+
+// orientedPolygon returns a copy of the polygon with a clockwise exterior
+// ring and counter-clockwise interior rings, or nil on errors. The polygon
+// is not normalized, as this would change the start vertex of the rings.
+func (g *Geos) orientedPolygon(polygon *Geom) *Geom {
+	exterior := g.ExteriorRing(polygon)
+	if exterior == nil {
+		return nil
+	}
+	shell, err := g.orientedRing(exterior, false)
+	if err != nil {
+		return nil
+	}
+	var holes []*Geom
+	for _, ring := range g.InteriorRings(polygon) {
+		hole, err := g.orientedRing(ring, true)
+		if err != nil {
+			g.Destroy(shell)
+			for _, h := range holes {
+				g.Destroy(h)
+			}
+			return nil
+		}
+		holes = append(holes, hole)
+	}
+	// shell and holes inherited by Polygon
+	result := g.createPolygon(shell, holes)
+	if result == nil {
+		g.Destroy(shell)
+		for _, h := range holes {
+			g.Destroy(h)
+		}
+	}
+	return result
+}
+
+// orientedRing returns a new LinearRing from ring, reversed if the
+// orientation does not match ccw.
+func (g *Geos) orientedRing(ring *Geom, ccw bool) (*Geom, error) {
+	coords, err := g.Coords(ring)
+	if err != nil {
+		return nil, err
+	}
+	if (g.SignedArea(ring) > 0) != ccw {
+		for i, j := 0, len(coords)-1; i < j; i, j = i+1, j-1 {
+			coords[i], coords[j] = coords[j], coords[i]
+		}
+	}
+	return g.LinearRing(coords)
+}
+
+// HasSelfTouch returns true if a coordinate appears more than once in the
+// LineString or LinearRing ring, not counting the closing coordinate (e.g.
+// for figure-eight rings). These rings are valid linestrings, but they are
+// invalid as polygons and need to be split into multipolygons (e.g. with
+// MakeValid).
+func (g *Geos) HasSelfTouch(ring *Geom) bool {
+	coords, err := g.Coords(ring)
+	if err != nil || len(coords) < 4 {
+		return false
+	}
+	if coords[0] == coords[len(coords)-1] {
+		coords = coords[:len(coords)-1]
+	}
+	seen := make(map[[2]float64]struct{}, len(coords))
+	for _, c := range coords {
+		if _, ok := seen[c]; ok {
+			return true
+		}
+		seen[c] = struct{}{}
+	}
+	return false
+}
+
+// PolygonizeRing builds the polygons of a self-touching ring (see
+// HasSelfTouch). The ring is noded at the touching coordinates and
+// polygonized. Loops inside of other loops (e.g. for inverted shells) are
+// holes. Returns a Polygon or MultiPolygon (e.g. two polygons for
+// figure-eight rings), or nil on errors or if the ring has no loops with
+// an area. ring is not destroyed.
+func (g *Geos) PolygonizeRing(ring *Geom) *Geom {
+	noded := C.GEOSUnaryUnion_r(g.v, ring.v)
+	if noded == nil {
+		return nil
+	}
+	defer C.GEOSGeom_destroy_r(g.v, noded)
+
+	polygonized := C.GEOSPolygonize_r(g.v, &noded, 1)
+	if polygonized == nil {
+		return nil
+	}
+	collection := newGeom(polygonized)
+	defer g.Destroy(collection)
+
+	faces := g.Geoms(collection)
+	var holes []*Geom
+	for _, face := range faces {
+		holes = append(holes, g.InteriorRings(face)...)
+	}
+	var shells []*Geom
+faces:
+	for _, face := range faces {
+		// polygonize also returns the faces of all holes
+		exterior := g.ExteriorRing(face)
+		for _, hole := range holes {
+			if g.Equals(exterior, hole) {
+				continue faces
+			}
+		}
+		shell := g.Clone(face)
+		if shell == nil {
+			for _, s := range shells {
+				g.Destroy(s)
+			}
+			return nil
+		}
+		shells = append(shells, shell)
+	}
+	if len(shells) == 0 {
+		return nil
+	}
+	return g.UnionPolygons(shells)
+}
+
+// ConvexHull returns the convex hull of geom. Returns nil on errors.
+func (g *Geos) ConvexHull(geom *Geom) *Geom {
+	hull := C.GEOSConvexHull_r(g.v, geom.v)
+	if hull == nil {
+		return nil
+	}
+	return newGeom(hull)
+}
+
+// Preview returns a lightweight stand-in for large geometries (e.g. for
+// indices or previews) with at most targetVertices vertices. geom is
+// simplified with SimplifyToVertexCount. The convex hull and finally the
+// envelope are used if the simplified geometry still has too many vertices
+// (e.g. for multipolygons with many parts). The envelope is returned for
+// targetVertices below 4. Returns nil on errors.
+func (g *Geos) Preview(geom *Geom, targetVertices int) *Geom {
+	if targetVertices >= 4 {
+		candidates := []func() *Geom{
+			func() *Geom { return g.SimplifyToVertexCount(geom, targetVertices) },
+			func() *Geom {
+				hull := g.ConvexHull(geom)
+				if hull == nil {
+					return nil
+				}
+				defer g.Destroy(hull)
+				return g.SimplifyToVertexCount(hull, targetVertices)
+			},
+		}
+		for _, candidate := range candidates {
+			preview := candidate()
+			if preview == nil {
+				continue
+			}
+			if int(g.NumCoordinates(preview)) <= targetVertices && !g.IsEmpty(preview) {
+				return preview
+			}
+			g.Destroy(preview)
+		}
+	}
+	return g.BoundsPolygon(geom.Bounds())
+}
+
+// SimplifyLevels returns one simplified geometry for each tolerance, in
+// the order of tolerances. The levels are simplified with
+// SimplifyPreserveTopology from the finest to the coarsest level, and each
+// level is built from the previous level to reduce the number of vertices
+// to process. Returns nil on errors.
+func (g *Geos) SimplifyLevels(geom *Geom, tolerances []float64) []*Geom {
+	order := make([]int, len(tolerances))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return tolerances[order[i]] < tolerances[order[j]]
+	})
+
+	result := make([]*Geom, len(tolerances))
+	prev := geom
+	for _, i := range order {
+		simplified := g.SimplifyPreserveTopology(prev, tolerances[i])
+		if simplified == nil {
+			for _, r := range result {
+				if r != nil {
+					g.Destroy(r)
+				}
+			}
+			return nil
+		}
+		result[i] = simplified
+		prev = simplified
+	}
+	return result
+}
+
+// SimplifyToVertexCount simplifies geom with SimplifyPreserveTopology, so
+// that the result has at most maxVertices coordinates. The tolerance is
+// searched between 0 and the size of the envelope. Returns a clone of geom
+// if it has no more than maxVertices coordinates, and the most simplified
+// geometry if the limit can not be reached (e.g. for multipolygons with
+// too many rings). Returns nil on errors.
+func (g *Geos) SimplifyToVertexCount(geom *Geom, maxVertices int) *Geom {
+	if int(g.NumCoordinates(geom)) <= maxVertices {
+		return g.Clone(geom)
+	}
+	bounds := geom.Bounds()
+	lo, hi := 0.0, math.Max(bounds.MaxX-bounds.MinX, bounds.MaxY-bounds.MinY)
+
+	best := g.SimplifyPreserveTopology(geom, hi)
+	if best == nil || int(g.NumCoordinates(best)) > maxVertices {
+		return best
+	}
+	for i := 0; i < 30; i++ {
+		tolerance := (lo + hi) / 2
+		simplified := g.SimplifyPreserveTopology(geom, tolerance)
+		if simplified == nil {
+			break
+		}
+		if int(g.NumCoordinates(simplified)) <= maxVertices {
+			g.Destroy(best)
+			best = simplified
+			hi = tolerance
+		} else {
+			g.Destroy(simplified)
+			lo = tolerance
+		}
+	}
+	return best
+}
+
+// SplitPolygon splits a Polygon or MultiPolygon along line. The boundary of
+// poly and line are noded and polygonized, and all resulting polygons
+// inside of poly are returned. Returns nil on errors.
+func (g *Geos) SplitPolygon(poly, line *Geom) []*Geom {
+	boundary := C.GEOSBoundary_r(g.v, poly.v)
+	if boundary == nil {
+		return nil
+	}
+	defer C.GEOSGeom_destroy_r(g.v, boundary)
+	noded := C.GEOSUnion_r(g.v, boundary, line.v)
+	if noded == nil {
+		return nil
+	}
+	defer C.GEOSGeom_destroy_r(g.v, noded)
+
+	polygonized := C.GEOSPolygonize_r(g.v, &noded, 1)
+	if polygonized == nil {
+		return nil
+	}
+	collection := newGeom(polygonized)
+	defer g.Destroy(collection)
+
+	var result []*Geom
+	for _, part := range g.Geoms(collection) {
+		// polygonize also returns polygons of line loops outside of poly
+		p := g.PointOnSurface(part)
+		if p == nil {
+			continue
+		}
+		inside := g.Contains(poly, p)
+		g.Destroy(p)
+		if inside {
+			result = append(result, g.Clone(part))
+		}
+	}
+	return result
+}
+
+// SimplifyKeepEndpoints simplifies a LineString like SimplifyPreserveTopology,
+// but it always keeps the first and last coordinate, so that simplified lines
+// still connect to their neighbours. Returns nil for other geometry types.
+func (g *Geos) SimplifyKeepEndpoints(line *Geom, tolerance float64) *Geom {
+	if g.TypeID(line) != LineStringTypeID {
+		return nil
+	}
+	simplified := g.SimplifyPreserveTopology(line, tolerance)
+	if simplified == nil {
+		return nil
+	}
+	orig, err := g.Coords(line)
+	if err != nil || len(orig) < 2 {
+		return simplified
+	}
+	coords, err := g.Coords(simplified)
+	if err != nil {
+		g.Destroy(simplified)
+		return nil
+	}
+	restored, changed := restoreEndpoints(orig, coords)
+	if !changed {
+		return simplified
+	}
+	g.Destroy(simplified)
+	result, err := g.lineString(restored)
+	if err != nil {
+		return nil
+	}
+	return result
+}
+
+// restoreEndpoints adds the first and last coordinate of orig to coords if
+// they are missing.
+func restoreEndpoints(orig, coords [][2]float64) ([][2]float64, bool) {
+	first, last := orig[0], orig[len(orig)-1]
+	changed := false
+	if len(coords) == 0 || coords[0] != first {
+		coords = append([][2]float64{first}, coords...)
+		changed = true
+	}
+	if coords[len(coords)-1] != last {
+		coords = append(coords, last)
+		changed = true
+	}
+	return coords, changed
 }
 
 // UnionPolygons tries to merge polygons.
 // Returns a single (Multi)Polygon.
 // Destroys polygons and returns new allocated (Multi)Polygon as necessary.
+// Duplicate polygons (e.g. from duplicate relation members) are
+// removed before the union.
 func (g *Geos) UnionPolygons(polygons []*Geom) *Geom {
 	if len(polygons) == 0 {
 		return nil
 	}
+	polygons = g.removeDuplicates(polygons)
 	if len(polygons) == 1 {
 		return polygons[0]
 	}
@@ -70,7 +847,167 @@ func (g *Geos) UnionPolygons(polygons []*Geom) *Geom {
 	if result == nil {
 		return nil
 	}
-	return &Geom{result}
+	return newGeom(result)
+}
+
+// CascadedUnion merges polygons like UnionPolygons, but it unions
+// batches of batchSize polygons first and then the results of the batches.
+// This keeps the intermediate geometries smaller for many polygons.
+// Destroys polygons and returns a new allocated (Multi)Polygon. Returns nil
+// if a union fails, all polygons and intermediate unions are destroyed in
+// this case.
+func (g *Geos) CascadedUnion(polygons []*Geom, batchSize int) *Geom {
+	if batchSize < 2 {
+		batchSize = 2
+	}
+	for len(polygons) > batchSize {
+		partials := make([]*Geom, 0, len(polygons)/batchSize+1)
+		for i := 0; i < len(polygons); i += batchSize {
+			end := i + batchSize
+			if end > len(polygons) {
+				end = len(polygons)
+			}
+			union := g.UnionPolygons(polygons[i:end])
+			if union == nil {
+				// the batch is destroyed by UnionPolygons
+				for _, p := range polygons[end:] {
+					g.Destroy(p)
+				}
+				for _, p := range partials {
+					g.Destroy(p)
+				}
+				return nil
+			}
+			partials = append(partials, g.polygonParts(union)...)
+		}
+		if len(partials) >= len(polygons) {
+			// nothing merged, e.g. for disjoint polygons
+			polygons = partials
+			break
+		}
+		polygons = partials
+	}
+	return g.UnionPolygons(polygons)
+}
+
+// NodeLines unions lines into a noded network. Lines are split at all
+// crossing and overlapping points (e.g. to prepare a routing graph).
+// Returns a MultiLineString with the segments between the nodes.
+// Destroys lines and returns a new allocated geometry.
+func (g *Geos) NodeLines(lines []*Geom) *Geom {
+	if len(lines) == 0 {
+		return nil
+	}
+	multiLine := g.MultiLineString(lines)
+	if multiLine == nil {
+		return nil
+	}
+	defer g.Destroy(multiLine)
+
+	result := C.GEOSUnaryUnion_r(g.v, multiLine.v)
+	if result == nil {
+		return nil
+	}
+	return newGeom(result)
+}
+
+// DissolveAdjacent merges all polygons that touch or overlap each other,
+// directly or through other polygons. Candidates are found with an Index.
+// Returns one new allocated (Multi)Polygon for each group of connected
+// polygons. polygons are not destroyed.
+func (g *Geos) DissolveAdjacent(polygons []*Geom) []*Geom {
+	index := g.CreateIndex()
+	defer g.DestroyIndex(index)
+	for _, p := range polygons {
+		g.IndexAdd(index, p)
+	}
+
+	// union-find of the polygon indices
+	parent := make([]int, len(polygons))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i, p := range polygons {
+		for _, j := range g.IndexQuery(index, p) {
+			if j <= i || find(i) == find(j) {
+				continue
+			}
+			if g.Intersects(p, polygons[j]) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]*Geom)
+	var roots []int
+	for i, p := range polygons {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], g.Clone(p))
+	}
+	result := make([]*Geom, 0, len(roots))
+	for _, root := range roots {
+		if union := g.UnionPolygons(groups[root]); union != nil {
+			result = append(result, union)
+		}
+	}
+	return result
+}
+
+// polygonParts returns the polygons of a (Multi)Polygon. Destroys geom
+// if it is a MultiPolygon.
+func (g *Geos) polygonParts(geom *Geom) []*Geom {
+	if g.TypeID(geom) != MultiPolygonTypeID {
+		return []*Geom{geom}
+	}
+	parts := g.Geoms(geom)
+	result := make([]*Geom, len(parts))
+	for i, part := range parts {
+		result[i] = g.Clone(part)
+	}
+	g.Destroy(geom)
+	return result
+}
+
+const duplicateTolerance = 1e-9
+
+// removeDuplicates removes (and destroys) all geometries that are equal to
+// a previous geometry. Geometries are only compared to geometries with
+// the same (rounded) bounds.
+func (g *Geos) removeDuplicates(geoms []*Geom) []*Geom {
+	if len(geoms) < 2 {
+		return geoms
+	}
+	round := func(v float64) float64 { return math.Round(v * 1e6) }
+	seen := make(map[Bounds][]*Geom, len(geoms))
+	result := geoms[:0]
+	for _, geom := range geoms {
+		b := geom.Bounds()
+		key := Bounds{round(b.MinX), round(b.MinY), round(b.MaxX), round(b.MaxY)}
+		duplicate := false
+		for _, other := range seen[key] {
+			if g.EqualsExact(geom, other, duplicateTolerance) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			g.Destroy(geom)
+			continue
+		}
+		seen[key] = append(seen[key], geom)
+		result = append(result, geom)
+	}
+	return result
 }
 
 // LineMerge tries to merge lines. Returns slice of LineStrings.
@@ -88,7 +1025,7 @@ func (g *Geos) LineMerge(lines []*Geom) []*Geom {
 	if merged == nil {
 		return nil
 	}
-	geom := &Geom{merged}
+	geom := newGeom(merged)
 	if g.Type(geom) == "LineString" {
 		return []*Geom{geom}
 	}
@@ -101,3 +1038,81 @@ func (g *Geos) LineMerge(lines []*Geom) []*Geom {
 	g.Destroy(geom)
 	return lines
 }
+
+// SplitAtAntimeridian splits a WGS84 LineString into multiple LineStrings
+// if it crosses the antimeridian (±180°). A crossing is detected by a
+// longitude difference larger than 180° between two coordinates. The
+// latitude at the crossing is interpolated and added as an end- and
+// startpoint at 180° and -180°. Returns a clone of geom if it is not a
+// LineString or if it does not cross the antimeridian, so all returned
+// geometries are new and owned by the caller. Returns nil on errors.
+func (g *Geos) SplitAtAntimeridian(geom *Geom) []*Geom {
+	if g.TypeID(geom) != LineStringTypeID {
+		return g.cloneSlice(geom)
+	}
+	coords, err := g.Coords(geom)
+	if err != nil || len(coords) < 2 {
+		return g.cloneSlice(geom)
+	}
+
+	var parts [][][2]float64
+	part := [][2]float64{coords[0]}
+	for i := 1; i < len(coords); i++ {
+		prev, cur := coords[i-1], coords[i]
+		if math.Abs(cur[0]-prev[0]) > 180 {
+			// shift cur by 360° to get the continuous crossing segment
+			edge := 180.0
+			shiftedX := cur[0] + 360
+			if prev[0] < 0 {
+				edge = -180
+				shiftedX = cur[0] - 360
+			}
+			y := prev[1] + (cur[1]-prev[1])*(edge-prev[0])/(shiftedX-prev[0])
+			part = append(part, [2]float64{edge, y})
+			parts = append(parts, part)
+			part = [][2]float64{{-edge, y}}
+		}
+		part = append(part, cur)
+	}
+	if len(parts) == 0 {
+		return g.cloneSlice(geom)
+	}
+	parts = append(parts, part)
+
+	result := make([]*Geom, 0, len(parts))
+	for _, part := range parts {
+		line, err := g.lineString(part)
+		if err != nil {
+			for _, l := range result {
+				g.Destroy(l)
+			}
+			return nil
+		}
+		result = append(result, line)
+	}
+	return result
+}
+
+// cloneSlice returns a slice with a clone of geom, or nil on errors.
+func (g *Geos) cloneSlice(geom *Geom) []*Geom {
+	clone := g.Clone(geom)
+	if clone == nil {
+		return nil
+	}
+	return []*Geom{clone}
+}
+
+func (g *Geos) lineString(coords [][2]float64) (*Geom, error) {
+	coordSeq, err := g.CreateCoordSeq(uint32(len(coords)), 2)
+	if err != nil {
+		return nil, err
+	}
+	// coordSeq inherited by LineString, no destroy
+	for i, c := range coords {
+		if err := coordSeq.SetXY(g, uint32(i), c[0], c[1]); err != nil {
+			g.DestroyCoordSeq(coordSeq)
+			return nil, err
+		}
+	}
+	return coordSeq.AsLineString(g)
+}
@@ -6,7 +6,11 @@ package geos
 #include <stdlib.h>
 */
 import "C"
-import "github.com/omniscale/imposm3/log"
+import (
+	"container/list"
+
+	"github.com/omniscale/imposm3/log"
+)
 
 type PreparedGeom struct {
 	v *C.GEOSPreparedGeometry
@@ -38,6 +42,16 @@ func (g *Geos) PreparedIntersects(a *PreparedGeom, b *Geom) bool {
 	return false
 }
 
+// PreparedIntersectsBatch tests all bs against the prepared geometry a.
+// The result contains PreparedIntersects for each geometry of bs.
+func (g *Geos) PreparedIntersectsBatch(a *PreparedGeom, bs []*Geom) []bool {
+	result := make([]bool, len(bs))
+	for i, b := range bs {
+		result[i] = C.GEOSPreparedIntersects_r(g.v, a.v, b.v) == 1
+	}
+	return result
+}
+
 func (g *Geos) PreparedDestroy(geom *PreparedGeom) {
 	if geom.v != nil {
 		C.GEOSPreparedGeom_destroy_r(g.v, geom.v)
@@ -46,3 +60,68 @@ func (g *Geos) PreparedDestroy(geom *PreparedGeom) {
 		log.Printf("double free?")
 	}
 }
+
+// PreparedCache caches prepared geometries, so that repeated predicate
+// tests against the same geometry (e.g. a clip boundary) only prepare
+// it once. Geometries are keyed by identity, not by value. A cached
+// geometry must not be destroyed while it is in the cache.
+// PreparedCache is not safe for concurrent use.
+type PreparedCache struct {
+	size     int
+	prepared map[*Geom]*list.Element
+	// lru contains all entries, most recently used first
+	lru *list.List
+}
+
+type preparedEntry struct {
+	geom *Geom
+	prep *PreparedGeom
+}
+
+// NewPreparedCache returns a PreparedCache that holds up to size
+// prepared geometries. The least recently used entry is evicted if the
+// cache is full.
+func NewPreparedCache(size int) *PreparedCache {
+	if size < 1 {
+		size = 1
+	}
+	return &PreparedCache{
+		size:     size,
+		prepared: make(map[*Geom]*list.Element, size),
+		lru:      list.New(),
+	}
+}
+
+// PrepareCached returns the prepared geometry of geom from the cache,
+// or prepares and caches it. The prepared geometry is owned by the cache
+// and it is only valid until the next PrepareCached or
+// DestroyPreparedCache call, as it can be evicted and destroyed by
+// these calls.
+func (g *Geos) PrepareCached(cache *PreparedCache, geom *Geom) *PreparedGeom {
+	if elem, ok := cache.prepared[geom]; ok {
+		cache.lru.MoveToFront(elem)
+		return elem.Value.(*preparedEntry).prep
+	}
+	prep := g.Prepare(geom)
+	if prep == nil {
+		return nil
+	}
+
+	for cache.lru.Len() >= cache.size {
+		entry := cache.lru.Remove(cache.lru.Back()).(*preparedEntry)
+		delete(cache.prepared, entry.geom)
+		g.PreparedDestroy(entry.prep)
+	}
+	cache.prepared[geom] = cache.lru.PushFront(&preparedEntry{geom: geom, prep: prep})
+	return prep
+}
+
+// DestroyPreparedCache destroys all prepared geometries of the cache.
+// The original geometries are not destroyed.
+func (g *Geos) DestroyPreparedCache(cache *PreparedCache) {
+	for elem := cache.lru.Front(); elem != nil; elem = elem.Next() {
+		g.PreparedDestroy(elem.Value.(*preparedEntry).prep)
+	}
+	cache.prepared = make(map[*Geom]*list.Element, cache.size)
+	cache.lru.Init()
+}
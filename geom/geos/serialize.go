@@ -4,10 +4,42 @@ package geos
 #cgo LDFLAGS: -lgeos_c
 #include "geos_c.h"
 #include <stdlib.h>
+
+// wrap optional functions to keep the package buildable with older GEOS versions
+#define GEOS_AT_LEAST(major, minor) (GEOS_VERSION_MAJOR > major || (GEOS_VERSION_MAJOR == major && GEOS_VERSION_MINOR >= minor))
+
+static GEOSGeometry *fromGeoJSON(GEOSContextHandle_t handle, const char *geojson) {
+#if GEOS_AT_LEAST(3, 10)
+	GEOSGeoJSONReader *reader = GEOSGeoJSONReader_create_r(handle);
+	if (reader == NULL) {
+		return NULL;
+	}
+	GEOSGeometry *geom = GEOSGeoJSONReader_readGeometry_r(handle, reader, geojson);
+	GEOSGeoJSONReader_destroy_r(handle, reader);
+	return geom;
+#else
+	return NULL;
+#endif
+}
+
+static char *asGeoJSON(GEOSContextHandle_t handle, const GEOSGeometry *g) {
+#if GEOS_AT_LEAST(3, 10)
+	GEOSGeoJSONWriter *writer = GEOSGeoJSONWriter_create_r(handle);
+	if (writer == NULL) {
+		return NULL;
+	}
+	char *json = GEOSGeoJSONWriter_writeGeometry_r(handle, writer, g, -1);
+	GEOSGeoJSONWriter_destroy_r(handle, writer);
+	return json;
+#else
+	return NULL;
+#endif
+}
 */
 import "C"
 
 import (
+	"encoding/binary"
 	"unsafe"
 )
 
@@ -18,9 +50,11 @@ func (g *Geos) FromWkt(wkt string) *Geom {
 	if geom == nil {
 		return nil
 	}
-	return &Geom{geom}
+	return newGeom(geom)
 }
 
+// FromWkb reads a WKB or EWKB geometry. The SRID of EWKB input is set
+// as the SRID of the returned geometry.
 func (g *Geos) FromWkb(wkb []byte) *Geom {
 	if len(wkb) == 0 {
 		return nil
@@ -29,7 +63,58 @@ func (g *Geos) FromWkb(wkb []byte) *Geom {
 	if geom == nil {
 		return nil
 	}
-	return &Geom{geom}
+	if srid, ok := ewkbSRID(wkb); ok {
+		C.GEOSSetSRID_r(g.v, geom, C.int(srid))
+	}
+	return newGeom(geom)
+}
+
+// ewkbSridFlag is set in the geometry type of EWKB that includes a SRID
+const ewkbSridFlag = 0x20000000
+
+// ewkbSRID returns the SRID from the header of an EWKB geometry.
+// Returns false for WKB without SRID.
+func ewkbSRID(wkb []byte) (int, bool) {
+	if len(wkb) < 9 {
+		return 0, false
+	}
+	var order binary.ByteOrder = binary.BigEndian
+	if wkb[0] == 1 {
+		order = binary.LittleEndian
+	}
+	if order.Uint32(wkb[1:5])&ewkbSridFlag == 0 {
+		return 0, false
+	}
+	return int(int32(order.Uint32(wkb[5:9]))), true
+}
+
+// FromGeoJSON reads a GeoJSON geometry, feature or feature collection.
+// Requires GEOS 3.10.
+func (g *Geos) FromGeoJSON(geojson string) (*Geom, error) {
+	if !caps.GeoJSON {
+		return nil, unsupportedError("FromGeoJSON")
+	}
+	geojsonC := C.CString(geojson)
+	defer C.free(unsafe.Pointer(geojsonC))
+	geom := C.fromGeoJSON(g.v, geojsonC)
+	if geom == nil {
+		return nil, Error("unable to read GeoJSON")
+	}
+	return newGeom(geom), nil
+}
+
+// AsGeoJSON returns geom as GeoJSON geometry. Requires GEOS 3.10.
+func (g *Geos) AsGeoJSON(geom *Geom) (string, error) {
+	if !caps.GeoJSON {
+		return "", unsupportedError("AsGeoJSON")
+	}
+	str := C.asGeoJSON(g.v, geom.v)
+	if str == nil {
+		return "", Error("unable to write GeoJSON")
+	}
+	result := C.GoString(str)
+	C.GEOSFree_r(g.v, unsafe.Pointer(str))
+	return result, nil
 }
 
 func (g *Geos) AsWkt(geom *Geom) string {
@@ -42,7 +127,12 @@ func (g *Geos) AsWkt(geom *Geom) string {
 	return result
 }
 
+// AsWkb returns the WKB of geom. The WKB contains Z values
+// if geom has three coordinate dimensions.
 func (g *Geos) AsWkb(geom *Geom) []byte {
+	if g.CoordDimension(geom) == 3 {
+		return g.asWkb3D(geom)
+	}
 	var size C.size_t
 	buf := C.GEOSGeomToWKB_buf_r(g.v, geom.v, &size)
 	if buf == nil {
@@ -53,6 +143,55 @@ func (g *Geos) AsWkb(geom *Geom) []byte {
 	return result
 }
 
+// AsWkbByteOrder returns the WKB of geom in big endian (XDR) or little
+// endian (NDR) byte order. Like AsWkb, the WKB contains Z values if geom
+// has three coordinate dimensions.
+func (g *Geos) AsWkbByteOrder(geom *Geom, bigEndian bool) []byte {
+	writer := C.GEOSWKBWriter_create_r(g.v)
+	if writer == nil {
+		return nil
+	}
+	defer C.GEOSWKBWriter_destroy_r(g.v, writer)
+	if g.CoordDimension(geom) == 3 {
+		C.GEOSWKBWriter_setOutputDimension_r(g.v, writer, 3)
+	}
+	if bigEndian {
+		C.GEOSWKBWriter_setByteOrder_r(g.v, writer, C.GEOS_WKB_XDR)
+	} else {
+		C.GEOSWKBWriter_setByteOrder_r(g.v, writer, C.GEOS_WKB_NDR)
+	}
+
+	var size C.size_t
+	buf := C.GEOSWKBWriter_write_r(g.v, writer, geom.v, &size)
+	if buf == nil {
+		return nil
+	}
+	result := C.GoBytes(unsafe.Pointer(buf), C.int(size))
+	C.free(unsafe.Pointer(buf))
+	return result
+}
+
+func (g *Geos) asWkb3D(geom *Geom) []byte {
+	writer := C.GEOSWKBWriter_create_r(g.v)
+	if writer == nil {
+		return nil
+	}
+	defer C.GEOSWKBWriter_destroy_r(g.v, writer)
+	C.GEOSWKBWriter_setOutputDimension_r(g.v, writer, 3)
+
+	var size C.size_t
+	buf := C.GEOSWKBWriter_write_r(g.v, writer, geom.v, &size)
+	if buf == nil {
+		return nil
+	}
+	result := C.GoBytes(unsafe.Pointer(buf), C.int(size))
+	C.free(unsafe.Pointer(buf))
+	return result
+}
+
+// AsEwkbHex returns geom as hex encoded EWKB. The handle SRID is included
+// if it is set (see SetHandleSrid). The result can be used directly as a
+// geometry value for PostGIS (e.g. in COPY or INSERT statements).
 func (g *Geos) AsEwkbHex(geom *Geom) []byte {
 	if g.wkbwriter == nil {
 		g.wkbwriter = C.GEOSWKBWriter_create_r(g.v)
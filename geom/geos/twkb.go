@@ -0,0 +1,162 @@
+package geos
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// TWKB geometry types
+const (
+	twkbPoint              = 1
+	twkbLineString         = 2
+	twkbPolygon            = 3
+	twkbMultiPoint         = 4
+	twkbMultiLineString    = 5
+	twkbMultiPolygon       = 6
+	twkbGeometryCollection = 7
+
+	twkbEmptyFlag = 0x10
+)
+
+// AsTwkb returns geom as TWKB (Tiny Well-known Binary). Coordinates are
+// rounded to precision decimal places (-7 to 7, e.g. 0 for 1m with
+// EPSG:3857) and stored as delta-encoded varints. Only X/Y is encoded and
+// no bounding box, size or ID list is included.
+func (g *Geos) AsTwkb(geom *Geom, precision int) ([]byte, error) {
+	if precision < -7 || precision > 7 {
+		return nil, Error("TWKB precision needs to be between -7 and 7")
+	}
+	w := twkbWriter{g: g, precision: precision, scale: math.Pow(10, float64(precision))}
+	if err := w.writeGeom(geom); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+type twkbWriter struct {
+	g         *Geos
+	precision int
+	scale     float64
+	buf       []byte
+	// last coordinate, coordinates are stored as delta to the previous one
+	lastX int64
+	lastY int64
+}
+
+func (w *twkbWriter) writeGeom(geom *Geom) error {
+	var typ byte
+	switch w.g.TypeID(geom) {
+	case PointTypeID:
+		typ = twkbPoint
+	case LineStringTypeID, LinearRingTypeID:
+		typ = twkbLineString
+	case PolygonTypeID:
+		typ = twkbPolygon
+	case MultiPointTypeID:
+		typ = twkbMultiPoint
+	case MultiLineStringTypeID:
+		typ = twkbMultiLineString
+	case MultiPolygonTypeID:
+		typ = twkbMultiPolygon
+	case GeometryCollectionTypeID:
+		typ = twkbGeometryCollection
+	default:
+		return Error("unsupported geometry type for TWKB")
+	}
+	// type and zigzag encoded precision
+	zigzag := byte((w.precision << 1) ^ (w.precision >> 31))
+	w.buf = append(w.buf, typ|zigzag<<4)
+	if w.g.IsEmpty(geom) {
+		w.buf = append(w.buf, twkbEmptyFlag)
+		return nil
+	}
+	w.buf = append(w.buf, 0)
+
+	// each geometry of a collection starts with its own header
+	// and coordinates are relative to the start of the geometry
+	w.lastX, w.lastY = 0, 0
+
+	switch typ {
+	case twkbPoint:
+		return w.writeCoords(geom, false)
+	case twkbLineString:
+		return w.writeCoords(geom, true)
+	case twkbPolygon:
+		return w.writePolygon(geom)
+	}
+
+	parts := w.g.Geoms(geom)
+	if parts == nil {
+		return Error("unable to get geometries")
+	}
+	w.writeUvarint(uint64(len(parts)))
+	for _, part := range parts {
+		var err error
+		switch typ {
+		case twkbMultiPoint:
+			err = w.writeCoords(part, false)
+		case twkbMultiLineString:
+			err = w.writeCoords(part, true)
+		case twkbMultiPolygon:
+			err = w.writePolygon(part)
+		case twkbGeometryCollection:
+			err = w.writeGeom(part)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *twkbWriter) writePolygon(polygon *Geom) error {
+	shell := w.g.ExteriorRing(polygon)
+	if shell == nil {
+		return Error("unable to get exterior ring")
+	}
+	holes := w.g.InteriorRings(polygon)
+	if holes == nil {
+		return Error("unable to get interior rings")
+	}
+	w.writeUvarint(uint64(1 + len(holes)))
+	for _, ring := range append([]*Geom{shell}, holes...) {
+		if err := w.writeCoords(ring, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCoords writes the delta encoded coordinates of a Point, LineString
+// or LinearRing. withCount prefixes the coordinates with their number
+// (not for points).
+func (w *twkbWriter) writeCoords(geom *Geom, withCount bool) error {
+	coords, err := w.g.Coords(geom)
+	if err != nil {
+		return err
+	}
+	if withCount {
+		w.writeUvarint(uint64(len(coords)))
+	}
+	for _, c := range coords {
+		x := int64(math.Round(c[0] * w.scale))
+		y := int64(math.Round(c[1] * w.scale))
+		w.writeVarint(x - w.lastX)
+		w.writeVarint(y - w.lastY)
+		w.lastX, w.lastY = x, y
+	}
+	return nil
+}
+
+func (w *twkbWriter) writeUvarint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	w.buf = append(w.buf, tmp[:n]...)
+}
+
+// writeVarint writes v as zigzag encoded varint, as required by TWKB
+func (w *twkbWriter) writeVarint(v int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(tmp[:], v)
+	w.buf = append(w.buf, tmp[:n]...)
+}
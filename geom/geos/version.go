@@ -0,0 +1,105 @@
+package geos
+
+/*
+#cgo LDFLAGS: -lgeos_c
+#include "geos_c.h"
+*/
+import "C"
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Version returns the version of the linked GEOS library
+// (e.g. "3.11.1-CAPI-1.17.1").
+func Version() string {
+	return C.GoString(C.GEOSversion())
+}
+
+// VersionAtLeast returns true if the linked GEOS library is
+// at least version major.minor.
+func VersionAtLeast(major, minor int) bool {
+	libMajor, libMinor, ok := parseVersion(Version())
+	if !ok {
+		return false
+	}
+	if libMajor != major {
+		return libMajor > major
+	}
+	return libMinor >= minor
+}
+
+func parseVersion(version string) (major, minor int, ok bool) {
+	// strip -CAPI-x.y.z suffix and patch level
+	if i := strings.IndexByte(version, '-'); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	// minor can have a suffix (e.g. 3.12dev, 3.9rc1)
+	minorDigits := strings.IndexFunc(parts[1], func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if minorDigits >= 0 {
+		parts[1] = parts[1][:minorDigits]
+	}
+	minor, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// Capabilities lists optional GEOS functions that are only available
+// with newer GEOS versions. A capability is only true if it is supported
+// by the GEOS headers at build time and by the linked library at runtime.
+type Capabilities struct {
+	// ClipByRect: GEOSClipByRect (GEOS 3.5)
+	ClipByRect bool
+	// SetPrecision: GEOSGeom_setPrecision (GEOS 3.6)
+	SetPrecision bool
+	// MakeValid: GEOSMakeValid (GEOS 3.8)
+	MakeValid bool
+	// GeoJSON: GEOSGeoJSONReader/Writer (GEOS 3.10)
+	GeoJSON bool
+	// CoordSeqBuffer: GEOSCoordSeq_copyFromBuffer/copyToBuffer (GEOS 3.10)
+	CoordSeqBuffer bool
+}
+
+var caps Capabilities
+
+func init() {
+	caps = Capabilities{
+		ClipByRect:     supports(3, 5),
+		SetPrecision:   supports(3, 6),
+		MakeValid:      supports(3, 8),
+		GeoJSON:        supports(3, 10),
+		CoordSeqBuffer: supports(3, 10),
+	}
+}
+
+// Caps returns the optional GEOS functions supported by this build.
+func Caps() Capabilities {
+	return caps
+}
+
+// supports returns true if the GEOS headers and the linked
+// GEOS library are at least version major.minor.
+func supports(major, minor int) bool {
+	headerMajor, headerMinor := int(C.GEOS_VERSION_MAJOR), int(C.GEOS_VERSION_MINOR)
+	if headerMajor < major || (headerMajor == major && headerMinor < minor) {
+		return false
+	}
+	return VersionAtLeast(major, minor)
+}
+
+func unsupportedError(function string) error {
+	return Error(function + " unsupported by linked GEOS " + Version())
+}
@@ -9,9 +9,10 @@ import (
 )
 
 type PreparedRelation struct {
-	rings []*ring
-	rel   *osm.Relation
-	srid  int
+	rings       []*ring
+	rel         *osm.Relation
+	srid        int
+	keepInvalid bool
 }
 
 // PrepareRelation is the first step in building a (multi-)polygon of a Relation.
@@ -22,7 +23,12 @@ func PrepareRelation(rel *osm.Relation, srid int, maxRingGap float64) (PreparedR
 		return PreparedRelation{}, err
 	}
 
-	return PreparedRelation{rings, rel, srid}, nil
+	return PreparedRelation{rings: rings, rel: rel, srid: srid}, nil
+}
+
+// KeepInvalid disables the repair of invalid geometries in Build.
+func (prep *PreparedRelation) KeepInvalid() {
+	prep.keepInvalid = true
 }
 
 // Build creates the (multi)polygon Geometry of the Relation.
@@ -31,7 +37,7 @@ func (prep *PreparedRelation) Build() (Geometry, error) {
 	g.SetHandleSrid(prep.srid)
 	defer g.Finish()
 
-	geom, err := buildRelGeometry(g, prep.rel, prep.rings)
+	geom, err := buildRelGeometry(g, prep.rel, prep.rings, !prep.keepInvalid)
 	if err != nil {
 		return Geometry{}, err
 	}
@@ -79,7 +85,7 @@ func buildRings(rel *osm.Relation, maxRingGap float64) ([]*ring, error) {
 	// create geometries for closed rings, collect incomplete rings
 	for _, r := range rings {
 		if r.isClosed() {
-			r.geom, err = Polygon(g, r.nodes)
+			r.geom, err = polygon(g, r.nodes)
 			if err != nil {
 				return nil, err
 			}
@@ -96,7 +102,7 @@ func buildRings(rel *osm.Relation, maxRingGap float64) ([]*ring, error) {
 		if !ring.isClosed() && !ring.tryClose(maxRingGap) {
 			continue
 		}
-		ring.geom, err = Polygon(g, ring.nodes)
+		ring.geom, err = polygon(g, ring.nodes)
 		if err != nil {
 			return nil, err
 		}
@@ -124,8 +130,10 @@ func (r sortableRingsDesc) Less(i, j int) bool { return r[i].area > r[j].area }
 func (r sortableRingsDesc) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
 
 // buildRelGeometry builds the geometry of rel by creating a multipolygon of all rings.
-// rings need to be sorted by area (large to small).
-func buildRelGeometry(g *geos.Geos, rel *osm.Relation, rings []*ring) (*geos.Geom, error) {
+// rings need to be sorted by area (large to small). Invalid geometries
+// are repaired if makeValid is true.
+func buildRelGeometry(g *geos.Geos, rel *osm.Relation, rings []*ring, makeValid bool) (_ *geos.Geom, err error) {
+	defer func() { BuildStats.count(err) }()
 	totalRings := len(rings)
 	shells := map[*ring]bool{rings[0]: true}
 	for i := 0; i < totalRings; i++ {
@@ -193,10 +201,11 @@ func buildRelGeometry(g *geos.Geos, rel *osm.Relation, rings []*ring) (*geos.Geo
 			return nil, errors.New("unable to build mulipolygon")
 		}
 	}
-	var err error
-	result, err = g.MakeValid(result)
-	if err != nil {
-		return nil, err
+	if makeValid {
+		result, err = MakeValid(g, result)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	g.DestroyLater(result)
@@ -236,3 +245,97 @@ func ringIsHole(rings []*ring, idx int) bool {
 	}
 	return containedCounter%2 == 1
 }
+
+// BuildRelation builds a MultiPolygon from closed outer and inner rings.
+// Each inner ring is added as a hole to the smallest outer ring that
+// contains it. Unlike PrepareRelation, it does not merge unclosed ways and
+// it relies on the roles of the rings. Returns a GeometryError for
+// rings that are not closed or for inner rings outside of all outer rings.
+func BuildRelation(g *geos.Geos, outerWays, innerWays [][]osm.Node) (*Geometry, error) {
+	if len(outerWays) == 0 {
+		return nil, ErrorNoRing
+	}
+	var outers, inners []*geos.Geom
+	var holes [][]*geos.Geom
+	var polygons []*geos.Geom
+	defer func() {
+		// outers and inners are only used for the rings, holes and
+		// polygons are set to nil once they are inherited
+		destroyGeoms(g, outers)
+		destroyGeoms(g, inners)
+		for _, h := range holes {
+			destroyGeoms(g, h)
+		}
+		destroyGeoms(g, polygons)
+	}()
+
+	for _, nodes := range outerWays {
+		geom, err := ringPolygon(g, nodes)
+		if err != nil {
+			return nil, err
+		}
+		outers = append(outers, geom)
+	}
+	holes = make([][]*geos.Geom, len(outers))
+	for _, nodes := range innerWays {
+		inner, err := ringPolygon(g, nodes)
+		if err != nil {
+			return nil, err
+		}
+		inners = append(inners, inner)
+		shell := -1
+		for i, outer := range outers {
+			if g.Contains(outer, inner) && (shell == -1 || outer.Area() < outers[shell].Area()) {
+				shell = i
+			}
+		}
+		if shell == -1 {
+			return nil, newGeometryError("inner ring not inside of an outer ring", 0)
+		}
+		holes[shell] = append(holes[shell], g.Clone(g.ExteriorRing(inner)))
+	}
+
+	for i, outer := range outers {
+		exterior := g.Clone(g.ExteriorRing(outer))
+		polygon := g.Polygon(exterior, holes[i])
+		if polygon == nil {
+			g.Destroy(exterior)
+			return nil, errors.New("unable to build polygon")
+		}
+		// holes inherited by polygon
+		holes[i] = nil
+		polygons = append(polygons, polygon)
+	}
+	// polygons inherited by MultiPolygon, also on errors
+	result := g.MultiPolygon(polygons)
+	polygons = nil
+	if result == nil {
+		return nil, errors.New("unable to build multipolygon")
+	}
+	g.DestroyLater(result)
+
+	geom, err := AsGeomElement(g, result)
+	if err != nil {
+		return nil, err
+	}
+	return &geom, nil
+}
+
+// destroyGeoms destroys all geoms.
+func destroyGeoms(g *geos.Geos, geoms []*geos.Geom) {
+	for _, geom := range geoms {
+		g.Destroy(geom)
+	}
+}
+
+// ringPolygon returns a Polygon for a closed ring of nodes.
+func ringPolygon(g *geos.Geos, nodes []osm.Node) (*geos.Geom, error) {
+	geom, err := polygon(g, nodes)
+	if err != nil {
+		if _, ok := err.(*GeometryError); ok {
+			return nil, err
+		}
+		return nil, newGeometryError("invalid ring: "+err.Error(), 0)
+	}
+	return geom, nil
+}
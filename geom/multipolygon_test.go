@@ -2,7 +2,9 @@ package geom
 
 import (
 	"math"
+	"runtime"
 	"testing"
+	"time"
 
 	osm "github.com/omniscale/go-osm"
 	"github.com/omniscale/imposm3/geom/geos"
@@ -659,3 +661,74 @@ func TestClosedAndOpenRing(t *testing.T) {
 		t.Fatal("geometry not valid", g.AsWkt(geom.Geom))
 	}
 }
+
+func squareNodes(x0, y0, x1, y1 float64) []osm.Node {
+	return []osm.Node{
+		{Long: x0, Lat: y0},
+		{Long: x1, Lat: y0},
+		{Long: x1, Lat: y1},
+		{Long: x0, Lat: y1},
+		{Long: x0, Lat: y0},
+	}
+}
+
+func TestBuildRelation(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	geom, err := BuildRelation(g,
+		[][]osm.Node{squareNodes(0, 0, 10, 10)},
+		[][]osm.Node{squareNodes(2, 2, 8, 8)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if area := geom.Geom.Area(); area != 100-36 {
+		t.Fatal("unexpected area", area)
+	}
+	if g.Type(geom.Geom) != "MultiPolygon" {
+		t.Fatal("not a multipolygon", g.Type(geom.Geom))
+	}
+	if len(geom.Wkb) == 0 {
+		t.Fatal("missing WKB")
+	}
+
+	// unclosed outer ring
+	_, err = BuildRelation(g, [][]osm.Node{squareNodes(0, 0, 10, 10)[:4]}, nil)
+	if _, ok := err.(*GeometryError); !ok {
+		t.Error("expected GeometryError, got", err)
+	}
+
+	// inner ring outside of outer ring
+	_, err = BuildRelation(g,
+		[][]osm.Node{squareNodes(0, 0, 10, 10)},
+		[][]osm.Node{squareNodes(20, 20, 30, 30)},
+	)
+	if _, ok := err.(*GeometryError); !ok {
+		t.Error("expected GeometryError, got", err)
+	}
+}
+
+func TestBuildRelationErrorCleanup(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	// settle finalizers of other tests
+	for i := 0; i < 3; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	baseline := geos.LiveGeomCount()
+
+	// first hole is already assigned when the second inner ring fails
+	_, err := BuildRelation(g,
+		[][]osm.Node{squareNodes(0, 0, 10, 10), squareNodes(20, 0, 30, 10)},
+		[][]osm.Node{squareNodes(2, 2, 8, 8), squareNodes(50, 50, 60, 60)},
+	)
+	if _, ok := err.(*GeometryError); !ok {
+		t.Fatal("expected GeometryError, got", err)
+	}
+	if c := geos.LiveGeomCount(); c != baseline {
+		t.Error("geometries not destroyed on error", c-baseline)
+	}
+}
@@ -1,6 +1,7 @@
 package geom
 
 import (
+	"errors"
 	"math"
 
 	osm "github.com/omniscale/go-osm"
@@ -51,6 +52,55 @@ func newRing(way *osm.Way) *ring {
 	return &r
 }
 
+// MergeRings merges unordered segments into closed rings. Segments do not
+// need to share the same direction, as it is common for ways of a relation.
+// Unlike mergeRings, segments are matched by their coordinates and not by
+// their node IDs. Returns ErrorNoRing if any merged segments do not form
+// a closed ring.
+func MergeRings(g *geos.Geos, segments [][]osm.Node) ([][]osm.Node, error) {
+	lines := make([]*geos.Geom, 0, len(segments))
+	for _, nodes := range segments {
+		line, err := lineString(g, nodes)
+		if err != nil {
+			for _, l := range lines {
+				g.Destroy(l)
+			}
+			return nil, err
+		}
+		// LineMerge destroys the lines, use a clone without finalizer
+		lines = append(lines, g.Clone(line))
+	}
+	if len(lines) == 0 {
+		return nil, ErrorNoRing
+	}
+	merged := g.LineMerge(lines)
+	if merged == nil {
+		return nil, errors.New("unable to merge segments")
+	}
+	defer func() {
+		for _, line := range merged {
+			g.Destroy(line)
+		}
+	}()
+
+	rings := make([][]osm.Node, 0, len(merged))
+	for _, line := range merged {
+		coords, err := g.Coords(line)
+		if err != nil {
+			return nil, err
+		}
+		if len(coords) < 4 || coords[0] != coords[len(coords)-1] {
+			return nil, ErrorNoRing
+		}
+		nodes := make([]osm.Node, len(coords))
+		for i, c := range coords {
+			nodes[i] = osm.Node{Long: c[0], Lat: c[1]}
+		}
+		rings = append(rings, nodes)
+	}
+	return rings, nil
+}
+
 func reverseRefs(refs []int64) {
 	for i, j := 0, len(refs)-1; i < j; i, j = i+1, j-1 {
 		refs[i], refs[j] = refs[j], refs[i]
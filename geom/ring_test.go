@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	osm "github.com/omniscale/go-osm"
+	"github.com/omniscale/imposm3/geom/geos"
 )
 
 func TestRingMerge(t *testing.T) {
@@ -247,3 +248,37 @@ func permutationNext(data sort.Interface) bool {
 	}
 	return true
 }
+
+func TestMergeRingsSegments(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	segments := [][]osm.Node{
+		{{Long: 10, Lat: 10}, {Long: 0, Lat: 10}},
+		{{Long: 0, Lat: 0}, {Long: 10, Lat: 0}},
+		{{Long: 0, Lat: 0}, {Long: 0, Lat: 10}}, // reversed
+		{{Long: 10, Lat: 0}, {Long: 10, Lat: 10}},
+	}
+	rings, err := MergeRings(g, segments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rings) != 1 {
+		t.Fatal("expected one ring", rings)
+	}
+	if len(rings[0]) != 5 || !nodesEqual(rings[0][0], rings[0][4]) {
+		t.Fatal("ring not closed", rings[0])
+	}
+	poly, err := Polygon(g, rings[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if poly.Area() != 100 {
+		t.Error("unexpected area", poly.Area())
+	}
+
+	_, err = MergeRings(g, segments[:3])
+	if err != ErrorNoRing {
+		t.Error("expected ErrorNoRing, got", err)
+	}
+}
@@ -0,0 +1,64 @@
+package geom
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/omniscale/imposm3/geom/geos"
+)
+
+// Stats counts the outcomes of the geometry builders. All fields are
+// updated atomically; use Snapshot to read them.
+type Stats struct {
+	// Built counts all points, linestrings and (multi)polygons that were built.
+	Built int64
+	// Invalid counts all invalid polygons that were found.
+	Invalid int64
+	// Repaired counts all invalid polygons that were repaired.
+	Repaired int64
+	// Dropped counts all geometries that could not be built.
+	Dropped int64
+}
+
+// BuildStats are the statistics of all geometry builders of this package
+// (e.g. for periodic logging during imports).
+var BuildStats Stats
+
+// Snapshot returns a copy of the current counters.
+func (s *Stats) Snapshot() Stats {
+	return Stats{
+		Built:    atomic.LoadInt64(&s.Built),
+		Invalid:  atomic.LoadInt64(&s.Invalid),
+		Repaired: atomic.LoadInt64(&s.Repaired),
+		Dropped:  atomic.LoadInt64(&s.Dropped),
+	}
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("built: %d, invalid: %d, repaired: %d, dropped: %d",
+		s.Built, s.Invalid, s.Repaired, s.Dropped)
+}
+
+// count updates the Built or Dropped counter, depending on err.
+func (s *Stats) count(err error) {
+	if err != nil {
+		atomic.AddInt64(&s.Dropped, 1)
+	} else {
+		atomic.AddInt64(&s.Built, 1)
+	}
+}
+
+// MakeValid repairs geom if it is invalid (see geos.MakeValid) and counts
+// invalid and repaired geometries in BuildStats.
+func MakeValid(g *geos.Geos, geom *geos.Geom) (*geos.Geom, error) {
+	if g.IsValid(geom) {
+		return geom, nil
+	}
+	atomic.AddInt64(&BuildStats.Invalid, 1)
+	result, err := g.MakeValid(geom)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&BuildStats.Repaired, 1)
+	return result, nil
+}
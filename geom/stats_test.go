@@ -0,0 +1,58 @@
+package geom
+
+import (
+	"testing"
+
+	osm "github.com/omniscale/go-osm"
+	"github.com/omniscale/imposm3/geom/geos"
+)
+
+func TestBuildStats(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	before := BuildStats.Snapshot()
+
+	if _, err := LineString(g, []osm.Node{{Long: 0, Lat: 0}, {Long: 10, Lat: 0}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Polygon(g, []osm.Node{
+		{Long: 0, Lat: 0}, {Long: 10, Lat: 0}, {Long: 10, Lat: 10}, {Long: 0, Lat: 0},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LineString(g, []osm.Node{{Long: 0, Lat: 0}}); err == nil {
+		t.Fatal("one node way not rejected")
+	}
+
+	// relation with a self-intersecting (bowtie) ring is repaired
+	w1 := makeWay(1, osm.Tags{}, []coord{
+		{1, 0, 0},
+		{2, 10, 10},
+		{3, 10, 0},
+		{4, 0, 10},
+		{1, 0, 0},
+	})
+	rel := osm.Relation{Element: osm.Element{ID: 1, Tags: osm.Tags{}}}
+	rel.Members = []osm.Member{
+		{ID: 1, Type: osm.WayMember, Role: "outer", Way: &w1},
+	}
+	geom, err := buildRelation(&rel, 3857)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.IsValid(geom.Geom) {
+		t.Error("relation not repaired")
+	}
+
+	after := BuildStats.Snapshot()
+	diff := Stats{
+		Built:    after.Built - before.Built,
+		Invalid:  after.Invalid - before.Invalid,
+		Repaired: after.Repaired - before.Repaired,
+		Dropped:  after.Dropped - before.Dropped,
+	}
+	if diff != (Stats{Built: 3, Invalid: 1, Repaired: 1, Dropped: 1}) {
+		t.Error("unexpected stats", diff)
+	}
+}
@@ -0,0 +1,84 @@
+package geom
+
+import (
+	"sync"
+
+	osm "github.com/omniscale/go-osm"
+	"github.com/omniscale/imposm3/geom/geos"
+)
+
+// BuildJob is a way or relation that should be build by BuildWorkers.
+// Ways are build as LineString, or as Polygon if Polygon is true.
+// Relations are always build as (multi)polygon.
+type BuildJob struct {
+	Way      *osm.Way
+	Relation *osm.Relation
+	Polygon  bool
+}
+
+// BuildResult is the result of a BuildJob. Geometry is nil if Err is set.
+type BuildResult struct {
+	Job      BuildJob
+	Geometry *Geometry
+	Err      error
+}
+
+// BuildWorkers starts n goroutines that build the geometries of all jobs.
+// GEOS handles are not thread safe, so each goroutine uses its own handle,
+// with srid as the handle SRID. The results are not in the order of the jobs.
+// The returned channel is closed after jobs was closed and all jobs
+// are processed.
+func BuildWorkers(n int, srid int, maxRingGap float64, jobs <-chan BuildJob) <-chan BuildResult {
+	if n < 1 {
+		n = 1
+	}
+	results := make(chan BuildResult, n)
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := geos.NewGeos()
+			g.SetHandleSrid(srid)
+			defer g.Finish()
+
+			for job := range jobs {
+				geom, err := buildJob(g, job, maxRingGap)
+				results <- BuildResult{Job: job, Geometry: geom, Err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+func buildJob(g *geos.Geos, job BuildJob, maxRingGap float64) (*Geometry, error) {
+	var geosgeom *geos.Geom
+	var err error
+	switch {
+	case job.Way != nil && job.Polygon:
+		geosgeom, err = Polygon(g, job.Way.Nodes)
+	case job.Way != nil:
+		geosgeom, err = LineString(g, job.Way.Nodes)
+	case job.Relation != nil:
+		var rings []*ring
+		rings, err = buildRings(job.Relation, maxRingGap)
+		if err == nil {
+			geosgeom, err = buildRelGeometry(g, job.Relation, rings, true)
+		}
+	default:
+		return nil, newGeometryError("empty build job", 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// create WKB eagerly, g is not available after the worker stopped
+	geom, err := AsGeomElement(g, geosgeom)
+	if err != nil {
+		return nil, err
+	}
+	return &geom, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/omniscale/imposm3/config"
 	"github.com/omniscale/imposm3/database"
 	_ "github.com/omniscale/imposm3/database/postgis"
+	"github.com/omniscale/imposm3/geom"
 	"github.com/omniscale/imposm3/geom/limit"
 	"github.com/omniscale/imposm3/log"
 	"github.com/omniscale/imposm3/mapping"
@@ -47,7 +48,7 @@ func Import(importOpts config.Import) {
 		step()
 	}
 
-	tagmapping, err := mapping.FromFile(baseOpts.MappingFile)
+	tagmapping, err := mapping.FromFileWithSrid(baseOpts.MappingFile, baseOpts.Srid)
 	if err != nil {
 		log.Fatal("[error] reading mapping file: ", err)
 	}
@@ -188,6 +189,11 @@ func Import(importOpts config.Import) {
 			baseOpts.Srid,
 		)
 		relWriter.SetLimiter(geometryLimiter)
+		relWriter.SetBBox(tagmapping.BBox)
+		relWriter.SetGridSize(tagmapping.GridSize)
+		relWriter.SetSnapNodes(tagmapping.SnapNodes)
+		relWriter.SetMaxVertices(tagmapping.MaxVertices)
+		relWriter.SetInvalidPolicy(tagmapping.InvalidPolicy)
 		relWriter.EnableConcurrent()
 		relWriter.Start()
 		relWriter.Wait() // blocks till the Relations.Iter() finishes
@@ -203,6 +209,12 @@ func Import(importOpts config.Import) {
 			baseOpts.Srid,
 		)
 		wayWriter.SetLimiter(geometryLimiter)
+		wayWriter.SetBBox(tagmapping.BBox)
+		wayWriter.SetGridSize(tagmapping.GridSize)
+		wayWriter.SetSnapNodes(tagmapping.SnapNodes)
+		wayWriter.SetMaxVertices(tagmapping.MaxVertices)
+		wayWriter.SetRejectZeroArea(tagmapping.RejectZeroArea)
+		wayWriter.SetInvalidPolicy(tagmapping.InvalidPolicy)
 		wayWriter.EnableConcurrent()
 		wayWriter.Start()
 		wayWriter.Wait() // blocks till the Ways.Iter() finishes
@@ -215,6 +227,8 @@ func Import(importOpts config.Import) {
 			baseOpts.Srid,
 		)
 		nodeWriter.SetLimiter(geometryLimiter)
+		nodeWriter.SetBBox(tagmapping.BBox)
+		nodeWriter.SetGridSize(tagmapping.GridSize)
 		nodeWriter.EnableConcurrent()
 		nodeWriter.Start()
 		nodeWriter.Wait() // blocks till the Nodes.Iter() finishes
@@ -226,6 +240,7 @@ func Import(importOpts config.Import) {
 		}
 
 		progress.Stop()
+		log.Printf("[info] geometries %s", geom.BuildStats.Snapshot())
 
 		if importOpts.Diff {
 			diffCache.Close()
@@ -10,6 +10,7 @@ import (
 	"github.com/omniscale/imposm3/log"
 
 	"github.com/omniscale/imposm3/geom"
+	"github.com/omniscale/imposm3/geom/geos"
 	"github.com/omniscale/imposm3/mapping/config"
 	"github.com/pkg/errors"
 )
@@ -37,6 +38,7 @@ func init() {
 		"pseudoarea":           {"pseudoarea", "float32", nil, MakePseudoArea, nil, false},
 		"area":                 {"area", "float32", Area, nil, nil, false},
 		"webmerc_area":         {"webmerc_area", "float32", WebmercArea, nil, nil, false},
+		"compactness":          {"compactness", "float32", Compactness, nil, nil, false},
 		"zorder":               {"zorder", "int32", nil, MakeZOrder, nil, false},
 		"enumerate":            {"enumerate", "int32", nil, MakeEnumerate, nil, false},
 		"string_suffixreplace": {"string_suffixreplace", "string", nil, MakeSuffixReplace, nil, false},
@@ -44,7 +46,33 @@ func init() {
 		"categorize_int":             {Name: "categorize_int", GoType: "int32", MakeFunc: MakeCategorizeInt},
 		"geojson_intersects":         {Name: "geojson_intersects", GoType: "bool", MakeFunc: MakeIntersectsField},
 		"geojson_intersects_feature": {Name: "geojson_intersects_feature", GoType: "string", MakeFunc: MakeIntersectsFeatureField},
+		"simplified_geometry":        {Name: "simplified_geometry", GoType: "simplified_geometry", MakeFunc: MakeSimplifiedGeometry},
+		"orientation":                {Name: "orientation", GoType: "string", Func: Orientation},
+		"invalid_reason":             {Name: "invalid_reason", GoType: "string", Func: InvalidReason},
+		"osm_version":                {Name: "osm_version", GoType: "int32", Func: OSMVersion},
+		"osm_timestamp":              {Name: "osm_timestamp", GoType: "timestamp", Func: OSMTimestamp},
 	}
+	for name := range addressKeys {
+		AvailableColumnTypes[name] = ColumnType{Name: name, GoType: "string", Func: AddressPart}
+	}
+}
+
+// addressKeys are the default keys of the address column types.
+var addressKeys = map[string]string{
+	"address_street":      "addr:street",
+	"address_housenumber": "addr:housenumber",
+	"address_city":        "addr:city",
+	"address_postcode":    "addr:postcode",
+}
+
+// RegisterColumnType registers a custom column type that can be referenced
+// by name in the mapping. An existing column type with the same name is
+// replaced. ct.GoType needs to be supported by the database (e.g. string or
+// int32). Column types should be registered before the mapping is loaded;
+// RegisterColumnType is not safe for concurrent use.
+func RegisterColumnType(name string, ct ColumnType) {
+	ct.Name = name
+	AvailableColumnTypes[name] = ct
 }
 
 type MakeValue func(string, *osm.Element, *geom.Geometry, Match) interface{}
@@ -55,6 +83,51 @@ type MakeMakeValue func(string, ColumnType, config.Column) (MakeValue, error)
 type Key string
 type Value string
 
+// makeNormalize wraps makeValue and transforms all string values with
+// the normalize mode (lower, upper or trim).
+func makeNormalize(mode string, makeValue MakeValue) (MakeValue, error) {
+	var normalize func(string) string
+	switch mode {
+	case "lower":
+		normalize = strings.ToLower
+	case "upper":
+		normalize = strings.ToUpper
+	case "trim":
+		normalize = strings.TrimSpace
+	default:
+		return nil, errors.Errorf("unknown normalize %q, needs to be lower, upper or trim", mode)
+	}
+	return func(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
+		value := makeValue(val, elem, geom, match)
+		if s, ok := value.(string); ok {
+			return normalize(s)
+		}
+		return value
+	}, nil
+}
+
+// makePrecision wraps makeValue and rounds all coordinates of the geometry
+// to the gridSize before makeValue creates the WKB. Only the WKB of the
+// rounded geometry is created.
+func makePrecision(gridSize float64, makeValue MakeValue) MakeValue {
+	return func(val string, elem *osm.Element, geometry *geom.Geometry, match Match) interface{} {
+		if geometry.Geom == nil {
+			return makeValue(val, elem, geometry, match)
+		}
+		g, finish := geometryHandle(geometry)
+		defer finish()
+
+		reduced, err := g.SetPrecision(geometry.Geom, gridSize)
+		if err != nil {
+			log.Printf("[warn] unable to set precision of geometry: %s", err)
+			return nil
+		}
+		defer g.Destroy(reduced)
+		rounded := geom.LazyGeomElement(g, reduced)
+		return makeValue(val, elem, &rounded, match)
+	}
+}
+
 type ColumnType struct {
 	Name       string
 	GoType     string
@@ -82,6 +155,16 @@ func String(val string, elem *osm.Element, geom *geom.Geometry, match Match) int
 	return val
 }
 
+// AddressPart returns the trimmed value of an addr:* tag, or nil if the
+// value is empty.
+func AddressPart(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return nil
+	}
+	return val
+}
+
 func Integer(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
 	v, err := strconv.ParseInt(val, 10, 32)
 	if err != nil {
@@ -90,6 +173,17 @@ func Integer(val string, elem *osm.Element, geom *geom.Geometry, match Match) in
 	return v
 }
 
+// LeadingInteger returns the number at the start of val, e.g. 12 for
+// the house number 12a.
+func LeadingInteger(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
+	val = strings.TrimSpace(val)
+	end := 0
+	for end < len(val) && (val[end] >= '0' && val[end] <= '9' || end == 0 && val[end] == '-') {
+		end++
+	}
+	return Integer(val[:end], elem, geom, match)
+}
+
 func ID(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
 	return elem.ID
 }
@@ -102,6 +196,24 @@ func ValueName(val string, elem *osm.Element, geom *geom.Geometry, match Match)
 	return match.Value
 }
 
+// OSMVersion returns the version of the element, or nil if the
+// element has no metadata.
+func OSMVersion(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
+	if elem.Metadata == nil {
+		return nil
+	}
+	return elem.Metadata.Version
+}
+
+// OSMTimestamp returns the timestamp of the element, or nil if the
+// element has no metadata.
+func OSMTimestamp(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
+	if elem.Metadata == nil || elem.Metadata.Timestamp.IsZero() {
+		return nil
+	}
+	return elem.Metadata.Timestamp
+}
+
 func RelationMemberType(rel *osm.Relation, member *osm.Member, memberIndex int, match Match) interface{} {
 	return member.Type
 }
@@ -128,8 +240,110 @@ func Direction(val string, elem *osm.Element, geom *geom.Geometry, match Match)
 	}
 }
 
+// Geometry returns the EWKB of the geometry. Returns nil if the WKB could
+// not be created, rows with geometry columns are not inserted in this case.
 func Geometry(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
-	return string(geom.Wkb)
+	wkb := geom.EwkbHex()
+	if wkb == nil && geom.Geom != nil {
+		log.Println("[warn] could not create wkb")
+		return nil
+	}
+	return string(wkb)
+}
+
+func MakeSimplifiedGeometry(columnName string, columnType ColumnType, column config.Column) (MakeValue, error) {
+	_tolerance, ok := column.Args["tolerance"]
+	if !ok {
+		return nil, errors.New("missing tolerance in args for simplified_geometry")
+	}
+	var tolerance float64
+	switch t := _tolerance.(type) {
+	case float64:
+		tolerance = t
+	case int:
+		tolerance = float64(t)
+	default:
+		return nil, errors.New("tolerance in args for simplified_geometry not a number")
+	}
+	if tolerance <= 0 {
+		return nil, errors.New("tolerance in args for simplified_geometry needs to be positive")
+	}
+
+	simplifiedGeometry := func(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
+		if geom.Geom == nil {
+			return nil
+		}
+		g, finish := geometryHandle(geom)
+		defer finish()
+
+		simplified := g.SimplifyPreserveTopology(geom.Geom, tolerance)
+		if simplified == nil {
+			return nil
+		}
+		defer g.Destroy(simplified)
+		return string(g.AsEwkbHex(simplified))
+	}
+	return simplifiedGeometry, nil
+}
+
+// geometryHandle returns the handle that built geom (see
+// geom.LazyGeomElement), so that new geometries are written with the SRID
+// of the import. Other geometries get a new handle with the SRID of geom.
+// finish needs to be called after use.
+func geometryHandle(geom *geom.Geometry) (g *geos.Geos, finish func()) {
+	if g := geom.Geos(); g != nil {
+		return g, func() {}
+	}
+	g = geos.NewGeos()
+	g.SetHandleSrid(g.SRID(geom.Geom))
+	return g, g.Finish
+}
+
+// Orientation returns CW or CCW for the exterior ring of polygons (or of the
+// first polygon of multipolygons) and an empty string for all other geometries.
+func Orientation(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
+	if geom.Geom == nil {
+		return ""
+	}
+	g, finish := geometryHandle(geom)
+	defer finish()
+
+	polygon := geom.Geom
+	switch g.TypeID(polygon) {
+	case geos.PolygonTypeID:
+	case geos.MultiPolygonTypeID:
+		parts := g.Geoms(polygon)
+		if len(parts) == 0 {
+			return ""
+		}
+		polygon = parts[0]
+	default:
+		return ""
+	}
+	area := g.SignedArea(polygon)
+	if area == 0 {
+		return ""
+	}
+	if area > 0 {
+		return "CCW"
+	}
+	return "CW"
+}
+
+// InvalidReason returns the reason why the geometry is invalid (e.g.
+// "Self-intersection[5 5]") and an empty string for valid geometries.
+func InvalidReason(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
+	if geom.Geom == nil {
+		return ""
+	}
+	g, finish := geometryHandle(geom)
+	defer finish()
+
+	reason := g.IsValidReason(geom.Geom)
+	if reason == "Valid Geometry" {
+		return ""
+	}
+	return reason
 }
 
 func MakePseudoArea(columnName string, columnType ColumnType, column config.Column) (MakeValue, error) {
@@ -168,6 +382,21 @@ func WebmercArea(val string, elem *osm.Element, geom *geom.Geometry, match Match
 	return float32(area)
 }
 
+// Compactness returns the Polsby-Popper score (4*pi*area/perimeter²) of
+// polygons: 1 for circles and close to 0 for long and thin polygons.
+// Returns 0 for all other geometries.
+func Compactness(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
+	if geom.Geom == nil {
+		return nil
+	}
+	area := geom.Geom.Area()
+	perimeter := geom.Geom.Length()
+	if area == 0 || perimeter == 0 {
+		return float32(0)
+	}
+	return float32(4 * math.Pi * area / (perimeter * perimeter))
+}
+
 var hstoreReplacer = strings.NewReplacer("\\", "\\\\", "\"", "\\\"")
 
 func MakeHStoreString(columnName string, columnType ColumnType, column config.Column) (MakeValue, error) {
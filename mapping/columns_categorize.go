@@ -44,7 +44,21 @@ func MakeCategorizeInt(fieldName string, fieldType ColumnType, field config.Colu
 	}
 	defaultCategory := int(defaultCategoryF)
 
+	useMatch := false
+	if _useMatch, ok := field.Args["use_match_value"]; ok {
+		useMatch, ok = _useMatch.(bool)
+		if !ok {
+			return nil, fmt.Errorf("'use_match_value' in 'args' for categorize_int not a bool but %t", _useMatch)
+		}
+	}
+
 	makeValue := func(val string, elem *osm.Element, geom *geom.Geometry, m Match) interface{} {
+		if useMatch {
+			if cat, ok := valuesCategory[m.Value]; ok {
+				return cat
+			}
+			return defaultCategory
+		}
 		if val != "" {
 			if cat, ok := valuesCategory[val]; ok {
 				return cat
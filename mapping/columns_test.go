@@ -1,7 +1,12 @@
 package mapping
 
 import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	osm "github.com/omniscale/go-osm"
 	"github.com/omniscale/imposm3/geom"
@@ -71,6 +76,25 @@ func TestInteger(t *testing.T) {
 	}
 }
 
+func TestLeadingInteger(t *testing.T) {
+	for _, test := range []struct {
+		val      string
+		expected interface{}
+	}{
+		{"", nil},
+		{"a", nil},
+		{"12", int64(12)},
+		{"12a", int64(12)},
+		{" 7-9", int64(7)},
+		{"-3 b", int64(-3)},
+		{"1000000000000000000", nil},
+	} {
+		if v := LeadingInteger(test.val, nil, nil, Match{}); v != test.expected {
+			t.Errorf("%q -> %v, expected %v", test.val, v, test.expected)
+		}
+	}
+}
+
 func TestZOrder(t *testing.T) {
 	match := Match{}
 
@@ -284,6 +308,107 @@ func TestAreaColumn(t *testing.T) {
 	}
 }
 
+func TestSimplifiedGeometryColumn(t *testing.T) {
+	makeValue, err := MakeSimplifiedGeometry("geometry_simple", ColumnType{}, config.Column{
+		Name: "geometry_simple",
+		Type: "simplified_geometry",
+		Args: map[string]interface{}{"tolerance": 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := geos.NewGeos()
+	defer g.Finish()
+	g.SetHandleSrid(3857)
+
+	// dense line along a slight curve
+	wkt := "LINESTRING("
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			wkt += ","
+		}
+		wkt += fmt.Sprintf("%d %f", i*10, math.Sin(float64(i)/100)*20)
+	}
+	line := g.FromWkt(wkt + ")")
+	elem, err := geom.AsGeomElement(g, line)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := makeValue("", &osm.Element{}, &elem, Match{})
+	ewkb, ok := value.(string)
+	if !ok {
+		t.Fatal("unexpected value", value)
+	}
+	wkb, err := hex.DecodeString(ewkb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	simplified := g.FromWkb(wkb)
+	if simplified == nil {
+		t.Fatal("invalid EWKB")
+	}
+	coords, _ := g.Coords(simplified)
+	if len(coords) < 2 || len(coords) >= 100 {
+		t.Error("line not simplified", len(coords))
+	}
+	if g.SRID(simplified) != 3857 {
+		t.Error("unexpected SRID", g.SRID(simplified))
+	}
+
+	// only the simplified geometry is written for geometries of the import
+	lazy := geom.LazyGeomElement(g, g.FromWkt(wkt+")"))
+	if value := makeValue("", &osm.Element{}, &lazy, Match{}); value == nil {
+		t.Fatal("no value for lazy geometry")
+	}
+	if lazy.Wkb != nil {
+		t.Error("WKB of source geometry created")
+	}
+
+	if _, err := MakeSimplifiedGeometry("geometry_simple", ColumnType{}, config.Column{Type: "simplified_geometry"}); err == nil {
+		t.Error("missing tolerance not rejected")
+	}
+}
+
+func TestOrientationColumn(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	for _, test := range []struct {
+		wkt      string
+		expected string
+	}{
+		{"POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))", "CCW"},
+		{"POLYGON((0 0, 0 10, 10 10, 10 0, 0 0))", "CW"},
+		{"MULTIPOLYGON(((0 0, 0 10, 10 10, 10 0, 0 0)))", "CW"},
+		{"LINESTRING(0 0, 10 0, 10 10, 0 0)", ""},
+		{"POINT(0 0)", ""},
+	} {
+		elem := geom.Geometry{Geom: g.FromWkt(test.wkt)}
+		if v := Orientation("", nil, &elem, Match{}); v != test.expected {
+			t.Errorf("%s: %v != %v", test.wkt, v, test.expected)
+		}
+	}
+}
+
+func TestInvalidReasonColumn(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	valid := geom.Geometry{Geom: g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")}
+	if v := InvalidReason("", nil, &valid, Match{}); v != "" {
+		t.Error("unexpected reason for valid polygon", v)
+	}
+	bowtie := geom.Geometry{Geom: g.FromWkt("POLYGON((0 0, 10 10, 10 0, 0 10, 0 0))")}
+	if v := InvalidReason("", nil, &bowtie, Match{}); !strings.HasPrefix(v.(string), "Self-intersection") {
+		t.Error("unexpected reason for self-intersecting polygon", v)
+	}
+	if v := InvalidReason("", nil, &geom.Geometry{}, Match{}); v != "" {
+		t.Error("unexpected reason for missing geometry", v)
+	}
+}
+
 func TestMakeSuffixReplace(t *testing.T) {
 	column := config.Column{
 		Name: "name", Key: "name", Type: "string_suffixreplace",
@@ -352,3 +477,287 @@ func TestHstoreString(t *testing.T) {
 	}
 
 }
+
+func TestCategorizeIntMatch(t *testing.T) {
+	class, err := MakeCategorizeInt("class",
+		AvailableColumnTypes["categorize_int"],
+		config.Column{
+			Name: "class",
+			Type: "categorize_int",
+			Args: map[string]interface{}{
+				"values":          map[interface{}]interface{}{"motorway": 1, "trunk": 2, "primary": 3},
+				"default":         9,
+				"use_match_value": true,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		value    string
+		expected int
+	}{
+		{"motorway", 1},
+		{"trunk", 2},
+		{"primary", 3},
+		{"track", 9},
+		{"", 9},
+	} {
+		elem := &osm.Element{Tags: osm.Tags{"highway": test.value}}
+		if v := class("", elem, nil, Match{Key: "highway", Value: test.value}); v != test.expected {
+			t.Errorf("%s: %v != %d", test.value, v, test.expected)
+		}
+	}
+}
+
+func TestOSMMetadataColumns(t *testing.T) {
+	ts := time.Date(2020, 5, 4, 12, 30, 0, 0, time.UTC)
+	elem := &osm.Element{ID: 1, Metadata: &osm.Metadata{Version: 42, Timestamp: ts}}
+	if v := OSMVersion("", elem, nil, Match{}); v != int32(42) {
+		t.Error("unexpected version", v)
+	}
+	if v := OSMTimestamp("", elem, nil, Match{}); v != ts {
+		t.Error("unexpected timestamp", v)
+	}
+
+	elem = &osm.Element{ID: 1}
+	if v := OSMVersion("", elem, nil, Match{}); v != nil {
+		t.Error("unexpected version", v)
+	}
+	if v := OSMTimestamp("", elem, nil, Match{}); v != nil {
+		t.Error("unexpected timestamp", v)
+	}
+
+	m, err := New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        columns:
+        - {name: osm_id, type: id}
+        - {name: version, type: osm_version}
+        mapping:
+          highway: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.UsesMetadata() {
+		t.Error("metadata columns not detected")
+	}
+}
+
+func TestRegisterColumnType(t *testing.T) {
+	RegisterColumnType("test_upper", ColumnType{
+		GoType: "string",
+		Func: func(val string, elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
+			return strings.ToUpper(val)
+		},
+	})
+	defer delete(AvailableColumnTypes, "test_upper")
+
+	m, err := New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        columns:
+        - {name: osm_id, type: id}
+        - {name: name, key: name, type: test_upper}
+        mapping:
+          highway: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elem := osm.Way{}
+	elem.ID = 1
+	elem.Tags = osm.Tags{"highway": "primary", "name": "Main Street"}
+	matches := m.LineStringMatcher.MatchWay(&elem)
+	if len(matches) != 1 {
+		t.Fatal(matches)
+	}
+	row := matches[0].Row(&elem.Element, nil)
+	if len(row) != 2 || row[1] != "MAIN STREET" {
+		t.Error("unexpected row", row)
+	}
+}
+
+func TestGeometryColumnPrecision(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        columns:
+        - {name: osm_id, type: id}
+        - {name: geometry, type: geometry, precision: 2}
+        - {name: geometry_gen, type: geometry, precision: 0}
+        mapping:
+          highway: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := geos.NewGeos()
+	defer g.Finish()
+	g.SetHandleSrid(3857)
+	line := g.FromWkt("LINESTRING(1.23456 2.34567, 10.98765 20.87654)")
+	defer g.Destroy(line)
+	geometry := geom.LazyGeomElement(g, line)
+
+	elem := osm.Way{}
+	elem.ID = 1
+	elem.Tags = osm.Tags{"highway": "primary"}
+	matches := m.LineStringMatcher.MatchWay(&elem)
+	if len(matches) != 1 {
+		t.Fatal(matches)
+	}
+	row := matches[0].Row(&elem.Element, &geometry)
+	// only the rounded geometries are written
+	if geometry.Wkb != nil {
+		t.Error("WKB of unrounded geometry created")
+	}
+	for i, expected := range []string{
+		"LINESTRING(1.23 2.35, 10.99 20.88)",
+		"LINESTRING(1 2, 11 21)",
+	} {
+		wkb, err := hex.DecodeString(row[i+1].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rounded := g.FromWkb(wkb)
+		if !g.Equals(rounded, g.FromWkt(expected)) {
+			t.Errorf("unexpected geometry in column %d: %s", i+1, g.AsWkt(rounded))
+		}
+		if g.SRID(rounded) != 3857 {
+			t.Error("SRID not set", g.SRID(rounded))
+		}
+		g.Destroy(rounded)
+	}
+
+	_, err = New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        columns:
+        - {name: name, key: name, type: string, precision: 2}
+        mapping:
+          highway: [__any__]
+    `))
+	if err == nil {
+		t.Error("precision for string column not rejected")
+	}
+}
+
+func TestAddressColumns(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      buildings:
+        type: polygon
+        columns:
+        - {name: osm_id, type: id}
+        - {name: housenumber, type: address_housenumber}
+        - {name: street, type: address_street}
+        - {name: city, type: address_city, not_null: true, default: unknown}
+        - {name: postcode, key: "contact:postcode", type: address_postcode}
+        mapping:
+          building: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := m.Conf.Tables["buildings"].Columns[2].Key; key != "addr:street" {
+		t.Error("unexpected key", key)
+	}
+
+	elem := osm.Way{Refs: []int64{1, 2, 3, 1}}
+	elem.ID = 1
+	elem.Tags = osm.Tags{
+		"building":         "yes",
+		"addr:housenumber": " 12a ",
+		"addr:street":      "Main Street",
+		"addr:city":        " ",
+		"addr:postcode":    "12345",
+		"contact:postcode": "54321",
+	}
+	matches := m.PolygonMatcher.MatchWay(&elem)
+	if len(matches) != 1 {
+		t.Fatal(matches)
+	}
+	row := matches[0].Row(&elem.Element, nil)
+	expected := []interface{}{int64(1), "12a", "Main Street", "unknown", "54321"}
+	if fmt.Sprint(row) != fmt.Sprint(expected) {
+		t.Error("unexpected row", row)
+	}
+}
+
+func TestCompactnessColumn(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	compactness := func(wkt string) float32 {
+		geometry := geom.Geometry{Geom: g.FromWkt(wkt)}
+		return Compactness("", &osm.Element{}, &geometry, Match{}).(float32)
+	}
+
+	circle := g.Buffer(g.FromWkt("POINT(0 0)"), 100)
+	circleGeom := geom.Geometry{Geom: circle}
+	circleScore := Compactness("", &osm.Element{}, &circleGeom, Match{}).(float32)
+	rectScore := compactness("POLYGON((0 0, 1000 0, 1000 1, 0 1, 0 0))")
+	squareScore := compactness("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")
+
+	if circleScore < 0.99 || circleScore > 1 {
+		t.Error("unexpected circle compactness", circleScore)
+	}
+	if math.Abs(float64(squareScore)-math.Pi/4) > 1e-6 {
+		t.Error("unexpected square compactness", squareScore)
+	}
+	if !(circleScore > squareScore && squareScore > rectScore) {
+		t.Error("unexpected order", circleScore, squareScore, rectScore)
+	}
+	if v := compactness("LINESTRING(0 0, 10 10)"); v != 0 {
+		t.Error("unexpected linestring compactness", v)
+	}
+}
+
+func TestCategorizeIntWithoutMatchValue(t *testing.T) {
+	// without use_match_value, only val and keys are used
+	class, err := MakeCategorizeInt("class",
+		AvailableColumnTypes["categorize_int"],
+		config.Column{
+			Name: "class",
+			Type: "categorize_int",
+			Args: map[string]interface{}{
+				"values":  map[interface{}]interface{}{"motorway": 1, "trunk": 2},
+				"default": 9,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elem := &osm.Element{Tags: osm.Tags{"highway": "motorway"}}
+	if v := class("", elem, nil, Match{Key: "highway", Value: "motorway"}); v != 9 {
+		t.Error("mapping value used without use_match_value", v)
+	}
+	if v := class("trunk", elem, nil, Match{Key: "highway", Value: "motorway"}); v != 2 {
+		t.Error("unexpected value", v)
+	}
+
+	_, err = MakeCategorizeInt("class",
+		AvailableColumnTypes["categorize_int"],
+		config.Column{
+			Name: "class",
+			Type: "categorize_int",
+			Args: map[string]interface{}{
+				"values":          map[interface{}]interface{}{"motorway": 1},
+				"default":         9,
+				"use_match_value": "yes",
+			},
+		},
+	)
+	if err == nil {
+		t.Error("invalid use_match_value not rejected")
+	}
+}
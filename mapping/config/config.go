@@ -11,9 +11,16 @@ type Mapping struct {
 	GeneralizedTables GeneralizedTables `yaml:"generalized_tables"`
 	Tags              Tags              `yaml:"tags"`
 	Areas             Areas             `yaml:"areas"`
+	Geometries        Geometries        `yaml:"geometries"`
 	// SingleIDSpace mangles the overlapping node/way/relation IDs
 	// to be unique (nodes positive, ways negative, relations negative -1e17)
 	SingleIDSpace bool `yaml:"use_single_id_space"`
+	// StopOnFirstMatch inserts elements only into the matching table
+	// with the lowest mapping order.
+	StopOnFirstMatch bool `yaml:"stop_on_first_match"`
+	// BBox (min lon, min lat, max lon, max lat) rejects all elements
+	// that are completely outside of it.
+	BBox []float64 `yaml:"bbox"`
 }
 
 type Column struct {
@@ -23,6 +30,17 @@ type Column struct {
 	Type       string                 `yaml:"type"`
 	Args       map[string]interface{} `yaml:"args"`
 	FromMember bool                   `yaml:"from_member"`
+	// NotNull rows are not inserted if the column value is null,
+	// unless a Default value is set.
+	NotNull bool        `yaml:"not_null"`
+	Default interface{} `yaml:"default"`
+	// Normalize transforms string values (lower, upper or trim).
+	Normalize string `yaml:"normalize"`
+	// Parse int extracts the leading number of values for integer columns.
+	Parse string `yaml:"parse"`
+	// Precision is the number of decimal places of the coordinates
+	// for geometry columns.
+	Precision *int `yaml:"precision"`
 }
 
 type Tables map[string]*Table
@@ -36,6 +54,12 @@ type Table struct {
 	OldFields     []*Column             `yaml:"fields"`
 	Filters       *Filters              `yaml:"filters"`
 	RelationTypes []string              `yaml:"relation_types"`
+	// Centroids inserts an additional point on surface for each polygon
+	// into the <name>_point table (polygon tables only).
+	Centroids bool `yaml:"centroids"`
+	// ExcludeColumns removes columns by name, e.g. from columns that are
+	// shared with other tables by a YAML alias (relation_member tables only).
+	ExcludeColumns []string `yaml:"exclude_columns"`
 }
 
 type GeneralizedTables map[string]*GeneralizedTable
@@ -44,6 +68,10 @@ type GeneralizedTable struct {
 	SourceTableName string  `yaml:"source"`
 	Tolerance       float64 `yaml:"tolerance"`
 	SQLFilter       string  `yaml:"sql_filter"`
+	// MinZoom and MaxZoom are the optional zoom levels (inclusive)
+	// this table should be used for.
+	MinZoom *int `yaml:"min_zoom"`
+	MaxZoom *int `yaml:"max_zoom"`
 }
 
 type Filters struct {
@@ -52,6 +80,12 @@ type Filters struct {
 	Require       KeyValues      `yaml:"require"`
 	RejectRegexp  KeyRegexpValue `yaml:"reject_regexp"`
 	RequireRegexp KeyRegexpValue `yaml:"require_regexp"`
+	// RequirePresent rejects elements without all of these tags,
+	// regardless of their values.
+	RequirePresent []Key `yaml:"require_present"`
+	// MinArea rejects polygons with a smaller area (in units of the
+	// target SRID, so only supported for projected SRIDs).
+	MinArea float64 `yaml:"min_area"`
 }
 
 type Areas struct {
@@ -59,10 +93,33 @@ type Areas struct {
 	LinearTags []Key `yaml:"linear_tags"`
 }
 
+type Geometries struct {
+	// InvalidPolicy is skip, repair (default) or keep.
+	InvalidPolicy string `yaml:"invalid_policy"`
+	// Precision is the number of decimal places of all coordinates.
+	// Coordinates are not rounded if it is not set.
+	Precision *int `yaml:"precision"`
+	// SnapNodes rounds the node coordinates to the Precision before the
+	// geometries are built, so that adjacent features share identical
+	// coordinates.
+	SnapNodes bool `yaml:"snap_nodes"`
+	// MaxVertices simplifies linestrings and polygons with more vertices.
+	// Geometries are not simplified if it is not set.
+	MaxVertices int `yaml:"max_vertices"`
+	// MaxExtent drops geometries with a larger diagonal of their bounds
+	// (in the unit of the SRID). Geometries are not dropped if it is not set.
+	MaxExtent float64 `yaml:"max_extent"`
+	// RejectZeroArea skips polygons of ways without an area (e.g. all nodes
+	// are collinear).
+	RejectZeroArea bool `yaml:"reject_zero_area"`
+}
+
 type Tags struct {
 	LoadAll bool  `yaml:"load_all"`
 	Exclude []Key `yaml:"exclude"`
 	Include []Key `yaml:"include"`
+	// Aliases renames tags (alias: canonical key) before they are filtered.
+	Aliases map[Key]Key `yaml:"aliases"`
 }
 
 type Key string
@@ -13,6 +13,31 @@ type TagFilterer interface {
 }
 
 func (m *Mapping) NodeTagFilter() TagFilterer {
+	return m.withAliases(m.nodeTagFilter())
+}
+
+func (m *Mapping) WayTagFilter() TagFilterer {
+	return m.withAliases(m.wayTagFilter())
+}
+
+func (m *Mapping) RelationTagFilter() TagFilterer {
+	return m.withAliases(m.relationTagFilter())
+}
+
+// withAliases returns a filter that renames all aliased tags before they
+// are passed to f.
+func (m *Mapping) withAliases(f TagFilterer) TagFilterer {
+	if len(m.Conf.Tags.Aliases) == 0 {
+		return f
+	}
+	aliases := make(map[string]string, len(m.Conf.Tags.Aliases))
+	for alias, key := range m.Conf.Tags.Aliases {
+		aliases[string(alias)] = string(key)
+	}
+	return &aliasFilter{aliases: aliases, next: f}
+}
+
+func (m *Mapping) nodeTagFilter() TagFilterer {
 	if m.Conf.Tags.LoadAll {
 		return newExcludeFilter(m.Conf.Tags.Exclude)
 	}
@@ -24,7 +49,7 @@ func (m *Mapping) NodeTagFilter() TagFilterer {
 	return &tagFilter{mappings.asTagMap(), tags}
 }
 
-func (m *Mapping) WayTagFilter() TagFilterer {
+func (m *Mapping) wayTagFilter() TagFilterer {
 	if m.Conf.Tags.LoadAll {
 		return newExcludeFilter(m.Conf.Tags.Exclude)
 	}
@@ -38,7 +63,7 @@ func (m *Mapping) WayTagFilter() TagFilterer {
 	return &tagFilter{mappings.asTagMap(), tags}
 }
 
-func (m *Mapping) RelationTagFilter() TagFilterer {
+func (m *Mapping) relationTagFilter() TagFilterer {
 	if m.Conf.Tags.LoadAll {
 		return newExcludeFilter(m.Conf.Tags.Exclude)
 	}
@@ -61,6 +86,30 @@ func (m *Mapping) RelationTagFilter() TagFilterer {
 	return &tagFilter{mappings.asTagMap(), tags}
 }
 
+// aliasFilter renames aliased tags to their canonical key. Existing
+// tags with the canonical key take precedence over aliased tags.
+type aliasFilter struct {
+	aliases map[string]string
+	next    TagFilterer
+}
+
+func (f *aliasFilter) Filter(tags *osm.Tags) {
+	if tags == nil {
+		return
+	}
+	for alias, key := range f.aliases {
+		v, ok := (*tags)[alias]
+		if !ok {
+			continue
+		}
+		delete(*tags, alias)
+		if _, ok := (*tags)[key]; !ok {
+			(*tags)[key] = v
+		}
+	}
+	f.next.Filter(tags)
+}
+
 type tagMap map[Key]map[Value]struct{}
 
 type tagFilter struct {
@@ -444,6 +444,55 @@ tables:
 	)
 }
 
+func TestFilters_require_present(t *testing.T) {
+	filterTest(
+		t,
+		`
+tables:
+  named_roads:
+    columns:
+    - name: id
+      type: id
+    filters:
+      require_present: [name]
+    mapping:
+      highway: [__any__]
+    type: linestring
+`,
+		// Accept
+		[]osm.Tags{
+			osm.Tags{"highway": "primary", "name": "Main Street"},
+			osm.Tags{"highway": "track", "name": ""},
+			osm.Tags{"highway": "track", "name": "❤"},
+		},
+		// Reject
+		[]osm.Tags{
+			osm.Tags{"highway": "primary"},
+			osm.Tags{"highway": "primary", "name:en": "Main Street"},
+			osm.Tags{"name": "Main Street"},
+		},
+	)
+
+	m, err := New([]byte(`
+    tables:
+      named_roads:
+        type: linestring
+        filters:
+          require_present: [name]
+        mapping:
+          highway: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// name is not removed by the tag filter
+	tags := osm.Tags{"highway": "primary", "name": "Main Street"}
+	m.WayTagFilter().Filter(&tags)
+	if _, ok := tags["name"]; !ok {
+		t.Error("name removed by tag filter", tags)
+	}
+}
+
 func filterTest(t *testing.T, mapping string, accept []osm.Tags, reject []osm.Tags) {
 	var configTestMapping *Mapping
 	var err error
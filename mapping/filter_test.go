@@ -558,6 +558,46 @@ func TestPolygonMatcher_MatchRelation(t *testing.T) {
 	}
 }
 
+func TestTagFilterAliases(t *testing.T) {
+	mapping, err := New([]byte(`
+    tags:
+      aliases:
+        highway_1: highway
+    tables:
+      highways:
+        type: linestring
+        mapping:
+          highway:
+            - track
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tags     osm.Tags
+		expected osm.Tags
+	}{
+		{tags: osm.Tags{"highway_1": "track"}, expected: osm.Tags{"highway": "track"}},
+		{tags: osm.Tags{"highway_1": "unknown"}, expected: osm.Tags{}},
+		{tags: osm.Tags{"highway_1": "unknown", "highway": "track"}, expected: osm.Tags{"highway": "track"}},
+	}
+
+	ways := mapping.WayTagFilter()
+	for i, test := range tests {
+		ways.Filter(&test.tags)
+		if !stringMapEqual(test.tags, test.expected) {
+			t.Errorf("unexpected result for case %d: %v != %v", i+1, test.tags, test.expected)
+		}
+	}
+
+	elem := osm.Way{}
+	elem.Tags = osm.Tags{"highway_1": "track"}
+	ways.Filter(&elem.Tags)
+	if matches := mapping.LineStringMatcher.MatchWay(&elem); len(matches) != 1 || matches[0].Table.Name != "highways" {
+		t.Error("aliased way not matched", matches)
+	}
+}
+
 func TestExcludeFilter(t *testing.T) {
 	var f TagFilterer
 	var tags osm.Tags
@@ -656,3 +696,41 @@ func matchesEqual(expected []Match, actual []Match) bool {
 	}
 	return true
 }
+
+func TestAreaTagOnlyForWayTables(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      places:
+        type: point
+        mapping:
+          place: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := make(map[Key]bool)
+	m.extraTags(PointTable, tags)
+	if tags["area"] {
+		t.Error("area tag included for point-only mapping")
+	}
+
+	m, err = New([]byte(`
+    tables:
+      places:
+        type: point
+        mapping:
+          place: [__any__]
+      roads:
+        type: linestring
+        mapping:
+          highway: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags = make(map[Key]bool)
+	m.extraTags(LineStringTable, tags)
+	if !tags["area"] {
+		t.Error("area tag not included for linestring mapping")
+	}
+}
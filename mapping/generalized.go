@@ -0,0 +1,67 @@
+package mapping
+
+import (
+	"sort"
+
+	"github.com/omniscale/imposm3/mapping/config"
+)
+
+// GeneralizedTableForZoom returns the name of the table that serves the
+// zoom level for the table base. Only generalized tables (directly or
+// indirectly) derived from base with min_zoom or max_zoom are considered.
+// The generalized table with the smallest tolerance is returned if the zoom
+// ranges overlap. base itself is returned for zoom levels above all
+// generalized tables, or if there are no generalized tables with zoom
+// levels. Returns false if base is not a table or if no table serves zoom.
+func (m *Mapping) GeneralizedTableForZoom(base string, zoom int) (string, bool) {
+	if _, ok := m.Conf.Tables[base]; !ok {
+		return "", false
+	}
+	var matching []*config.GeneralizedTable
+	aboveAll := true
+	for _, t := range m.Conf.GeneralizedTables {
+		if t.MinZoom == nil && t.MaxZoom == nil {
+			continue
+		}
+		if m.generalizedSource(t) != base {
+			continue
+		}
+		if t.MaxZoom == nil || zoom <= *t.MaxZoom {
+			aboveAll = false
+		}
+		if (t.MinZoom == nil || zoom >= *t.MinZoom) && (t.MaxZoom == nil || zoom <= *t.MaxZoom) {
+			matching = append(matching, t)
+		}
+	}
+	if len(matching) == 0 {
+		if aboveAll {
+			return base, true
+		}
+		return "", false
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		if matching[i].Tolerance != matching[j].Tolerance {
+			return matching[i].Tolerance < matching[j].Tolerance
+		}
+		return matching[i].Name < matching[j].Name
+	})
+	return matching[0].Name, true
+}
+
+// generalizedSource returns the name of the (non-generalized) table the
+// generalized table t is derived from, or an empty string if the source
+// is unknown.
+func (m *Mapping) generalizedSource(t *config.GeneralizedTable) string {
+	// limit iterations to protect against circular sources
+	for i := 0; i <= len(m.Conf.GeneralizedTables); i++ {
+		source, ok := m.Conf.GeneralizedTables[t.SourceTableName]
+		if !ok {
+			if _, ok := m.Conf.Tables[t.SourceTableName]; ok {
+				return t.SourceTableName
+			}
+			return ""
+		}
+		t = source
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package mapping
+
+import "testing"
+
+func TestGeneralizedTableForZoom(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        mapping:
+          highway: [__any__]
+    generalized_tables:
+      roads_gen1:
+        source: roads
+        tolerance: 20
+        min_zoom: 9
+        max_zoom: 11
+      roads_gen0:
+        source: roads_gen1
+        tolerance: 200
+        min_zoom: 5
+        max_zoom: 8
+      roads_gen_unused:
+        source: roads
+        tolerance: 10
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		zoom  int
+		table string
+		ok    bool
+	}{
+		{4, "", false},
+		{5, "roads_gen0", true},
+		{8, "roads_gen0", true},
+		{9, "roads_gen1", true},
+		{11, "roads_gen1", true},
+		{12, "roads", true},
+		{18, "roads", true},
+	} {
+		table, ok := m.GeneralizedTableForZoom("roads", test.zoom)
+		if table != test.table || ok != test.ok {
+			t.Errorf("unexpected table for zoom %d: %q %v", test.zoom, table, ok)
+		}
+	}
+	if _, ok := m.GeneralizedTableForZoom("unknown", 10); ok {
+		t.Error("unknown table not rejected")
+	}
+
+	_, err = New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        mapping:
+          highway: [__any__]
+    generalized_tables:
+      roads_gen0:
+        source: roads
+        tolerance: 200
+        min_zoom: 8
+        max_zoom: 5
+    `))
+	if err == nil {
+		t.Error("invalid zoom range not rejected")
+	}
+}
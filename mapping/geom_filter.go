@@ -0,0 +1,70 @@
+package mapping
+
+import (
+	"math"
+
+	"github.com/omniscale/imposm3/geom"
+	"github.com/omniscale/imposm3/geom/geos"
+	"github.com/omniscale/imposm3/log"
+	"github.com/omniscale/imposm3/mapping/config"
+	"github.com/pkg/errors"
+)
+
+// geometryFilter returns false if the geometry should not be inserted
+// into the table. Unlike elementFilter, it is called after the
+// geometry was build.
+type geometryFilter func(geom *geom.Geometry) bool
+
+func makeGeometryFilters(tbl *config.Table, maxExtent float64) []geometryFilter {
+	var filters []geometryFilter
+	if maxExtent > 0 {
+		filters = append(filters, func(geom *geom.Geometry) bool {
+			if geom.Geom == nil {
+				return true
+			}
+			b := geom.Geom.Bounds()
+			if extent := math.Hypot(b.MaxX-b.MinX, b.MaxY-b.MinY); extent > maxExtent {
+				log.Printf("[warn] skipping geometry for %s, extent %f exceeds max_extent %f", tbl.Name, extent, maxExtent)
+				return false
+			}
+			return true
+		})
+	}
+	if tbl.Filters == nil {
+		return filters
+	}
+
+	if tbl.Filters.MinArea > 0 {
+		minArea := tbl.Filters.MinArea
+		filters = append(filters, func(geom *geom.Geometry) bool {
+			if geom.Geom == nil {
+				return true
+			}
+			// points and linestrings have no area
+			switch geom.Geom.TypeID() {
+			case geos.PolygonTypeID, geos.MultiPolygonTypeID:
+				return geom.Geom.Area() >= minArea
+			default:
+				return true
+			}
+		})
+	}
+	return filters
+}
+
+// CheckAreaFilter returns an error if the table uses an area filter
+// that is not meaningful for the srid. Areas of EPSG:4326 geometries
+// are in square degrees, so min_area requires a projected SRID.
+// Only negative values are checked if srid is 0.
+func CheckAreaFilter(tbl *config.Table, srid int) error {
+	if tbl.Filters == nil || tbl.Filters.MinArea == 0 {
+		return nil
+	}
+	if tbl.Filters.MinArea < 0 {
+		return errors.Errorf("min_area filter of table %s is negative", tbl.Name)
+	}
+	if srid == 4326 {
+		return errors.Errorf("min_area filter of table %s requires a projected SRID (e.g. 3857), not EPSG:4326", tbl.Name)
+	}
+	return nil
+}
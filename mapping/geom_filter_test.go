@@ -0,0 +1,236 @@
+package mapping
+
+import (
+	"testing"
+
+	osm "github.com/omniscale/go-osm"
+	"github.com/omniscale/imposm3/geom"
+	"github.com/omniscale/imposm3/geom/geos"
+)
+
+func TestMinAreaFilter(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      landusages:
+        type: polygon
+        filters:
+          min_area: 50
+        mapping:
+          landuse: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	elem := osm.Way{Refs: []int64{1, 2, 3, 1}}
+	elem.Tags = osm.Tags{"landuse": "forest"}
+	matches := m.PolygonMatcher.MatchWay(&elem)
+	if len(matches) != 1 {
+		t.Fatal(matches)
+	}
+
+	small := geom.Geometry{Geom: g.FromWkt("POLYGON((0 0, 5 0, 5 5, 0 5, 0 0))")}
+	if matches[0].AcceptGeometry(&small) {
+		t.Error("small polygon not rejected")
+	}
+	large := geom.Geometry{Geom: g.FromWkt("POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))")}
+	if !matches[0].AcceptGeometry(&large) {
+		t.Error("large polygon rejected")
+	}
+
+	tbl := m.Conf.Tables["landusages"]
+	if err := CheckAreaFilter(tbl, 3857); err != nil {
+		t.Error(err)
+	}
+	if err := CheckAreaFilter(tbl, 4326); err == nil {
+		t.Error("min_area filter with EPSG:4326 not rejected")
+	}
+
+	conf := []byte(`
+    tables:
+      landusages:
+        type: polygon
+        filters:
+          min_area: 50
+        mapping:
+          landuse: [__any__]
+    `)
+	if _, err := NewWithSrid(conf, 3857); err != nil {
+		t.Error(err)
+	}
+	if _, err := NewWithSrid(conf, 4326); err == nil {
+		t.Error("mapping with min_area filter for EPSG:4326 not rejected")
+	}
+}
+
+func TestMinAreaFilterNonPolygons(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      features:
+        type: geometry
+        filters:
+          min_area: 50
+        type_mappings:
+          points:
+            amenity: [__any__]
+          linestrings:
+            highway: [__any__]
+          polygons:
+            landuse: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	elem := osm.Way{Refs: []int64{1, 2}}
+	elem.Tags = osm.Tags{"highway": "primary"}
+	matches := m.LineStringMatcher.MatchWay(&elem)
+	if len(matches) != 1 {
+		t.Fatal(matches)
+	}
+	line := geom.Geometry{Geom: g.FromWkt("LINESTRING(0 0, 1 1)")}
+	if !matches[0].AcceptGeometry(&line) {
+		t.Error("linestring rejected by min_area")
+	}
+	point := geom.Geometry{Geom: g.FromWkt("POINT(0 0)")}
+	if !matches[0].AcceptGeometry(&point) {
+		t.Error("point rejected by min_area")
+	}
+	small := geom.Geometry{Geom: g.FromWkt("MULTIPOLYGON(((0 0, 5 0, 5 5, 0 5, 0 0)))")}
+	if matches[0].AcceptGeometry(&small) {
+		t.Error("small multipolygon not rejected")
+	}
+}
+
+func TestMaxExtentFilter(t *testing.T) {
+	m, err := New([]byte(`
+    geometries:
+      max_extent: 1000
+    tables:
+      roads:
+        type: linestring
+        mapping:
+          highway: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	elem := osm.Way{Refs: []int64{1, 2}}
+	elem.Tags = osm.Tags{"highway": "primary"}
+	matches := m.LineStringMatcher.MatchWay(&elem)
+	if len(matches) != 1 {
+		t.Fatal(matches)
+	}
+
+	local := geom.Geometry{Geom: g.FromWkt("LINESTRING(0 0, 500 500)")}
+	if !matches[0].AcceptGeometry(&local) {
+		t.Error("local line rejected")
+	}
+	global := geom.Geometry{Geom: g.FromWkt("LINESTRING(-20000000 0, 20000000 100)")}
+	if matches[0].AcceptGeometry(&global) {
+		t.Error("globe-spanning line not rejected")
+	}
+
+	if _, err := New([]byte("geometries: {max_extent: -1}")); err == nil {
+		t.Error("negative max_extent not rejected")
+	}
+}
+
+func TestRejectedGeometryNotSerialized(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      landusages:
+        type: polygon
+        filters:
+          min_area: 50
+        mapping:
+          landuse: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	elem := osm.Way{Refs: []int64{1, 2, 3, 4, 1}}
+	elem.Tags = osm.Tags{"landuse": "forest"}
+	matches := m.PolygonMatcher.MatchWay(&elem)
+	if len(matches) != 1 {
+		t.Fatal(matches)
+	}
+
+	square := func(size float64) geom.Geometry {
+		nodes := []osm.Node{
+			{Long: 0, Lat: 0}, {Long: size, Lat: 0}, {Long: size, Lat: size},
+			{Long: 0, Lat: size}, {Long: 0, Lat: 0},
+		}
+		polygon, err := geom.Polygon(g, nodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return geom.LazyGeomElement(g, polygon)
+	}
+
+	small := square(5)
+	if matches[0].AcceptGeometry(&small) {
+		t.Fatal("small polygon not rejected")
+	}
+	large := square(10)
+	if !matches[0].AcceptGeometry(&large) {
+		t.Fatal("large polygon rejected")
+	}
+	if small.Wkb != nil || large.Wkb != nil {
+		t.Error("WKB written by geometry filters")
+	}
+	if large.EwkbHex() == nil || large.Wkb == nil {
+		t.Error("WKB not written on first access")
+	}
+}
+
+func TestRowWithoutWkb(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        columns:
+        - {name: osm_id, type: id}
+        - {name: geometry, type: geometry}
+        mapping:
+          highway: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	elem := osm.Way{Refs: []int64{1, 2}}
+	elem.ID = 1
+	elem.Tags = osm.Tags{"highway": "primary"}
+	matches := m.LineStringMatcher.MatchWay(&elem)
+	if len(matches) != 1 {
+		t.Fatal(matches)
+	}
+
+	line := geom.LazyGeomElement(g, g.FromWkt("LINESTRING(0 0, 1 1)"))
+	if row := matches[0].Row(&elem.Element, &line); len(row) != 2 || row[1] == "" {
+		t.Error("unexpected row", row)
+	}
+	// no WKB can be created without handle
+	unbound := geom.Geometry{Geom: g.FromWkt("LINESTRING(0 0, 1 1)")}
+	if row := matches[0].Row(&elem.Element, &unbound); row != nil {
+		t.Error("row without WKB not rejected", row)
+	}
+}
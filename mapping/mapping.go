@@ -2,9 +2,13 @@ package mapping
 
 import (
 	"io/ioutil"
+	"math"
 	"regexp"
+	"sort"
+	"strings"
 
 	osm "github.com/omniscale/go-osm"
+	"github.com/omniscale/imposm3/geom/geos"
 	"github.com/omniscale/imposm3/log"
 	"github.com/omniscale/imposm3/mapping/config"
 
@@ -81,8 +85,35 @@ const (
 	RelationMemberTable TableType = "relation_member"
 )
 
+// InvalidPolicy defines how invalid polygons are handled.
+type InvalidPolicy string
+
+const (
+	// InvalidSkip does not insert invalid polygons.
+	InvalidSkip InvalidPolicy = "skip"
+	// InvalidRepair repairs invalid polygons with MakeValid.
+	InvalidRepair InvalidPolicy = "repair"
+	// InvalidKeep inserts invalid polygons as they are.
+	InvalidKeep InvalidPolicy = "keep"
+)
+
 type Mapping struct {
-	Conf                  config.Mapping
+	Conf          config.Mapping
+	InvalidPolicy InvalidPolicy
+	// GridSize for rounding of all coordinates, 0 if coordinates
+	// should not be rounded.
+	GridSize float64
+	// SnapNodes is true if node coordinates should be rounded to GridSize
+	// before the geometries are built.
+	SnapNodes bool
+	// MaxVertices of linestrings and polygons, 0 if geometries
+	// should not be simplified.
+	MaxVertices int
+	// RejectZeroArea is true if polygons of ways without an area
+	// should be skipped.
+	RejectZeroArea bool
+	// BBox in EPSG:4326, nil if elements are not filtered by bbox.
+	BBox                  *geos.Bounds
 	PointMatcher          NodeMatcher
 	LineStringMatcher     WayMatcher
 	PolygonMatcher        RelWayMatcher
@@ -91,21 +122,33 @@ type Mapping struct {
 }
 
 func FromFile(filename string) (*Mapping, error) {
+	return FromFileWithSrid(filename, 0)
+}
+
+// FromFileWithSrid is like FromFile, but it also checks that the mapping
+// can be used for geometries in the srid (e.g. for min_area filters).
+func FromFileWithSrid(filename string, srid int) (*Mapping, error) {
 	b, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	return New(b)
+	return NewWithSrid(b, srid)
 }
 
 func New(b []byte) (*Mapping, error) {
+	return NewWithSrid(b, 0)
+}
+
+// NewWithSrid is like New, but it also checks that the mapping can be used
+// for geometries in the srid. The srid is not checked if it is 0.
+func NewWithSrid(b []byte, srid int) (*Mapping, error) {
 	mapping := Mapping{}
 	err := yaml.Unmarshal(b, &mapping.Conf)
 	if err != nil {
 		return nil, err
 	}
 
-	err = mapping.prepare()
+	err = mapping.prepare(srid)
 	if err != nil {
 		return nil, err
 	}
@@ -117,7 +160,7 @@ func New(b []byte) (*Mapping, error) {
 	return &mapping, nil
 }
 
-func (m *Mapping) prepare() error {
+func (m *Mapping) prepare(srid int) error {
 	for name, t := range m.Conf.Tables {
 		t.Name = name
 		if t.OldFields != nil {
@@ -127,20 +170,169 @@ func (m *Mapping) prepare() error {
 		if t.Type == "" {
 			return errors.Errorf("missing type for table %s", name)
 		}
+		if t.ExcludeColumns != nil {
+			if TableType(t.Type) != RelationMemberTable {
+				return errors.Errorf("exclude_columns requires type:relation_member for table %s", name)
+			}
+			columns, err := excludeColumns(t.Columns, t.ExcludeColumns)
+			if err != nil {
+				return errors.Wrapf(err, "table %s", name)
+			}
+			t.Columns = columns
+		}
+		seenRelTypes := make(map[string]bool, len(t.RelationTypes))
+		for _, rtype := range t.RelationTypes {
+			if strings.TrimSpace(rtype) == "" {
+				return errors.Errorf("empty relation_types value for table %s", name)
+			}
+			if seenRelTypes[rtype] {
+				log.Printf("[warn] duplicate relation_types value %q for table %s", rtype, name)
+			}
+			seenRelTypes[rtype] = true
+		}
+		for _, c := range t.Columns {
+			if key, ok := addressKeys[c.Type]; ok && c.Key == "" && len(c.Keys) == 0 {
+				c.Key = config.Key(key)
+			}
+		}
+
+		if err := CheckAreaFilter(t, srid); err != nil {
+			return err
+		}
 
 		if TableType(t.Type) == GeometryTable {
 			if t.Mapping != nil || t.Mappings != nil {
 				return errors.Errorf("table with type:geometry requires type_mappings for table %s", name)
 			}
 		}
+		if t.Centroids {
+			if TableType(t.Type) != PolygonTable {
+				return errors.Errorf("centroids requires type:polygon for table %s", name)
+			}
+			if _, ok := m.Conf.Tables[CentroidTableName(name)]; ok {
+				return errors.Errorf("centroids of table %s conflict with existing table %s", name, CentroidTableName(name))
+			}
+		}
 	}
 
 	for name, t := range m.Conf.GeneralizedTables {
 		t.Name = name
+		if _, ok := m.Conf.Tables[name]; ok {
+			return errors.Errorf("generalized table %s conflicts with table %s", name, name)
+		}
+		if (t.MinZoom != nil && *t.MinZoom < 0) || (t.MaxZoom != nil && *t.MaxZoom < 0) {
+			return errors.Errorf("min_zoom and max_zoom of generalized table %s need to be positive", name)
+		}
+		if t.MinZoom != nil && t.MaxZoom != nil && *t.MinZoom > *t.MaxZoom {
+			return errors.Errorf("min_zoom of generalized table %s is larger than max_zoom", name)
+		}
 	}
+	for name, t := range m.Conf.Tables {
+		if _, ok := m.Conf.GeneralizedTables[CentroidTableName(name)]; ok && t.Centroids {
+			return errors.Errorf("centroids of table %s conflict with generalized table %s", name, CentroidTableName(name))
+		}
+	}
+
+	switch policy := InvalidPolicy(m.Conf.Geometries.InvalidPolicy); policy {
+	case "":
+		m.InvalidPolicy = InvalidRepair
+	case InvalidSkip, InvalidRepair, InvalidKeep:
+		m.InvalidPolicy = policy
+	default:
+		return errors.Errorf("unknown geometries.invalid_policy %q (skip, repair or keep)", policy)
+	}
+
+	if p := m.Conf.Geometries.Precision; p != nil {
+		if *p < 0 {
+			return errors.Errorf("geometries.precision needs to be positive, got %d", *p)
+		}
+		m.GridSize = math.Pow(10, -float64(*p))
+	}
+	if m.Conf.Geometries.SnapNodes {
+		if m.GridSize == 0 {
+			return errors.New("geometries.snap_nodes requires geometries.precision")
+		}
+		m.SnapNodes = true
+	}
+	if m.Conf.Geometries.MaxExtent < 0 {
+		return errors.Errorf("geometries.max_extent needs to be positive, got %v", m.Conf.Geometries.MaxExtent)
+	}
+	if bbox := m.Conf.BBox; bbox != nil {
+		if len(bbox) != 4 || bbox[0] >= bbox[2] || bbox[1] >= bbox[3] {
+			return errors.Errorf("bbox needs to be [min lon, min lat, max lon, max lat], got %v", bbox)
+		}
+		bounds := geos.MakeBounds(bbox[0], bbox[1], bbox[2], bbox[3])
+		m.BBox = &bounds
+	}
+	if n := m.Conf.Geometries.MaxVertices; n != 0 {
+		if n < 4 {
+			return errors.Errorf("geometries.max_vertices needs to be at least 4, got %d", n)
+		}
+		m.MaxVertices = n
+	}
+	m.RejectZeroArea = m.Conf.Geometries.RejectZeroArea
 	return nil
 }
 
+func excludeColumns(columns []*config.Column, exclude []string) ([]*config.Column, error) {
+	excluded := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		excluded[name] = false
+	}
+	result := make([]*config.Column, 0, len(columns))
+	for _, c := range columns {
+		if _, ok := excluded[c.Name]; ok {
+			excluded[c.Name] = true
+			continue
+		}
+		result = append(result, c)
+	}
+	for _, name := range exclude {
+		if !excluded[name] {
+			return nil, errors.Errorf("excluded column %s not found", name)
+		}
+	}
+	return result, nil
+}
+
+// RelationTables returns the sorted names of all tables that require
+// relations: relation and relation_member tables, and polygon tables
+// for multipolygon relations (or RelationTypes).
+func (m *Mapping) RelationTables() []string {
+	var names []string
+	for name, t := range m.Conf.Tables {
+		switch TableType(t.Type) {
+		case RelationTable, RelationMemberTable, PolygonTable:
+			names = append(names, name)
+		case GeometryTable:
+			if t.TypeMappings.Polygons != nil {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UsesRelations returns true if any table requires relations.
+func (m *Mapping) UsesRelations() bool {
+	return len(m.RelationTables()) > 0
+}
+
+// UsesMetadata returns true if any table has a column with the version or
+// timestamp of the elements. The metadata is only read from the input
+// files if it is required.
+func (m *Mapping) UsesMetadata() bool {
+	for _, t := range m.Conf.Tables {
+		for _, c := range t.Columns {
+			if c.Type == "osm_version" || c.Type == "osm_timestamp" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (m *Mapping) createMatcher() error {
 	var err error
 	m.PointMatcher, err = m.pointMatcher()
@@ -193,7 +385,7 @@ func (m *Mapping) tables(tableType TableType) (map[string]*rowBuilder, error) {
 	result := make(map[string]*rowBuilder)
 	for name, t := range m.Conf.Tables {
 		if TableType(t.Type) == tableType || TableType(t.Type) == GeometryTable {
-			result[name], err = makeRowBuilder(t)
+			result[name], err = makeRowBuilder(t, m.Conf.Geometries.MaxExtent)
 			if err != nil {
 				return nil, errors.Wrapf(err, "creating row builder for %s", name)
 			}
@@ -203,23 +395,43 @@ func (m *Mapping) tables(tableType TableType) (map[string]*rowBuilder, error) {
 	return result, nil
 }
 
-func makeRowBuilder(tbl *config.Table) (*rowBuilder, error) {
+func makeRowBuilder(tbl *config.Table, maxExtent float64) (*rowBuilder, error) {
 	result := rowBuilder{}
 
 	for _, mappingColumn := range tbl.Columns {
 		column := valueBuilder{}
 		column.key = Key(mappingColumn.Key)
+		if mappingColumn.Default != nil && !mappingColumn.NotNull {
+			return nil, errors.Errorf("default value requires not_null for column %s", mappingColumn.Name)
+		}
+		column.notNull = mappingColumn.NotNull
+		column.defaultValue = mappingColumn.Default
 
 		columnType, err := MakeColumnType(mappingColumn)
 		if err != nil {
 			return nil, errors.Wrapf(err, "creating column %s", mappingColumn.Name)
 		}
 		column.colType = *columnType
+		if columnType.GoType == "geometry" || columnType.GoType == "validated_geometry" {
+			// skip rows without WKB, see Geometry
+			column.notNull = true
+		}
 		result.columns = append(result.columns, column)
 	}
+	result.geomFilters = makeGeometryFilters(tbl, maxExtent)
+	if tbl.Centroids {
+		// centroids are inserted for all accepted polygons, no geomFilters
+		result.centroid = &rowBuilder{columns: result.columns}
+	}
 	return &result, nil
 }
 
+// CentroidTableName returns the name of the table for the centroids
+// of the polygon table name.
+func CentroidTableName(name string) string {
+	return name + "_point"
+}
+
 func MakeColumnType(c *config.Column) (*ColumnType, error) {
 	columnType, ok := AvailableColumnTypes[c.Type]
 	if !ok {
@@ -233,6 +445,36 @@ func MakeColumnType(c *config.Column) (*ColumnType, error) {
 		}
 		columnType = ColumnType{columnType.Name, columnType.GoType, makeValue, nil, nil, columnType.FromMember}
 	}
+	if c.Parse != "" {
+		if c.Parse != "int" {
+			return nil, errors.Errorf("unknown parse %q, only int is supported", c.Parse)
+		}
+		if c.Type != "integer" {
+			return nil, errors.Errorf("parse int requires integer column, not %s", c.Type)
+		}
+		columnType.Func = LeadingInteger
+	}
+	if c.Normalize != "" {
+		if columnType.Func == nil {
+			return nil, errors.Errorf("normalize not supported for %s columns", c.Type)
+		}
+		makeValue, err := makeNormalize(c.Normalize, columnType.Func)
+		if err != nil {
+			return nil, err
+		}
+		columnType.Func = makeValue
+	}
+	if c.Precision != nil {
+		switch columnType.GoType {
+		case "geometry", "validated_geometry", "simplified_geometry":
+		default:
+			return nil, errors.Errorf("precision not supported for %s columns", c.Type)
+		}
+		if *c.Precision < 0 {
+			return nil, errors.Errorf("precision needs to be positive, got %d", *c.Precision)
+		}
+		columnType.Func = makePrecision(math.Pow(10, -float64(*c.Precision)), columnType.Func)
+	}
 	columnType.FromMember = c.FromMember
 	return &columnType, nil
 }
@@ -257,6 +499,11 @@ func (m *Mapping) extraTags(tableType TableType, tags map[Key]bool) {
 				tags[Key(keyVal[0])] = true
 			}
 		}
+		if t.Filters != nil {
+			for _, k := range t.Filters.RequirePresent {
+				tags[Key(k)] = true
+			}
+		}
 
 		if tableType == PolygonTable || tableType == RelationTable || tableType == RelationMemberTable {
 			if t.RelationTypes != nil {
@@ -268,8 +515,22 @@ func (m *Mapping) extraTags(tableType TableType, tags map[Key]bool) {
 		tags[Key(k)] = true
 	}
 
-	// always include area tag for closed-way handling
-	tags["area"] = true
+	// include area tag for closed-way handling
+	if m.usesWayTables() {
+		tags["area"] = true
+	}
+}
+
+// usesWayTables returns true if any table imports ways as linestrings or
+// polygons.
+func (m *Mapping) usesWayTables() bool {
+	for _, t := range m.Conf.Tables {
+		switch TableType(t.Type) {
+		case LineStringTable, PolygonTable, GeometryTable:
+			return true
+		}
+	}
+	return false
 }
 
 type elementFilter func(tags osm.Tags, key Key, closed bool) bool
@@ -406,6 +667,18 @@ func (m *Mapping) addFilters(filters tableElementFilters) {
 			}
 		}
 
+		if t.Filters.RequirePresent != nil {
+			keys := t.Filters.RequirePresent
+			filters[name] = append(filters[name], func(tags osm.Tags, key Key, closed bool) bool {
+				for _, k := range keys {
+					if _, ok := tags[string(k)]; !ok {
+						return false
+					}
+				}
+				return true
+			})
+		}
+
 	}
 }
 
@@ -0,0 +1,181 @@
+package mapping
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInvalidPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		conf     string
+		expected InvalidPolicy
+	}{
+		{"tables: {}", InvalidRepair},
+		{"geometries: {invalid_policy: skip}", InvalidSkip},
+		{"geometries: {invalid_policy: repair}", InvalidRepair},
+		{"geometries: {invalid_policy: keep}", InvalidKeep},
+	} {
+		m, err := New([]byte(tc.conf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.InvalidPolicy != tc.expected {
+			t.Errorf("unexpected policy for %q: %s", tc.conf, m.InvalidPolicy)
+		}
+	}
+
+	if _, err := New([]byte("geometries: {invalid_policy: fix}")); err == nil {
+		t.Error("unknown policy not rejected")
+	}
+}
+
+func TestRelationTables(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        mapping:
+          highway: [__any__]
+      pois:
+        type: point
+        mapping:
+          amenity: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.UsesRelations() || len(m.RelationTables()) != 0 {
+		t.Error("unexpected relation tables", m.RelationTables())
+	}
+
+	m, err = New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        mapping:
+          highway: [__any__]
+      boundaries:
+        type: relation
+        relation_types: [boundary]
+        mapping:
+          boundary: [administrative]
+      landusages:
+        type: polygon
+        mapping:
+          landuse: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.UsesRelations() {
+		t.Error("relation tables not found")
+	}
+	if tables := m.RelationTables(); len(tables) != 2 || tables[0] != "boundaries" || tables[1] != "landusages" {
+		t.Error("unexpected relation tables", tables)
+	}
+}
+
+func TestGeometriesPrecision(t *testing.T) {
+	m, err := New([]byte("geometries: {precision: 2}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.GridSize != 0.01 {
+		t.Error("unexpected grid size", m.GridSize)
+	}
+
+	m, err = New([]byte("tables: {}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.GridSize != 0 {
+		t.Error("unexpected grid size", m.GridSize)
+	}
+
+	if _, err := New([]byte("geometries: {precision: -1}")); err == nil {
+		t.Error("negative precision not rejected")
+	}
+
+	m, err = New([]byte("geometries: {precision: 2, snap_nodes: true}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.SnapNodes {
+		t.Error("snap_nodes not enabled")
+	}
+	if _, err := New([]byte("geometries: {snap_nodes: true}")); err == nil {
+		t.Error("snap_nodes without precision not rejected")
+	}
+}
+
+func TestGeometriesMaxVertices(t *testing.T) {
+	m, err := New([]byte("geometries: {max_vertices: 100}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MaxVertices != 100 {
+		t.Error("unexpected max vertices", m.MaxVertices)
+	}
+	if _, err := New([]byte("geometries: {max_vertices: 2}")); err == nil {
+		t.Error("max_vertices below 4 not rejected")
+	}
+}
+
+func TestDuplicateTableNames(t *testing.T) {
+	_, err := New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        mapping:
+          highway: [__any__]
+    generalized_tables:
+      roads:
+        source: roads
+        tolerance: 50
+    `))
+	if err == nil || !strings.Contains(err.Error(), "generalized table roads conflicts") {
+		t.Error("duplicate table name not rejected", err)
+	}
+
+	_, err = New([]byte(`
+    tables:
+      buildings:
+        type: polygon
+        centroids: true
+        mapping:
+          building: [__any__]
+    generalized_tables:
+      buildings_point:
+        source: buildings
+        tolerance: 50
+    `))
+	if err == nil {
+		t.Error("centroid table name not rejected")
+	}
+}
+
+func TestRelationTypesValidation(t *testing.T) {
+	_, err := New([]byte(`
+    tables:
+      boundaries:
+        type: relation
+        relation_types: [boundary, ""]
+        mapping:
+          boundary: [administrative]
+    `))
+	if err == nil || !strings.Contains(err.Error(), "empty relation_types value for table boundaries") {
+		t.Error("empty relation type not rejected", err)
+	}
+
+	_, err = New([]byte(`
+    tables:
+      boundaries:
+        type: relation
+        relation_types: [boundary, boundary]
+        mapping:
+          boundary: [administrative]
+    `))
+	if err != nil {
+		t.Error("duplicate relation type rejected", err)
+	}
+}
@@ -12,12 +12,7 @@ func (m *Mapping) pointMatcher() (NodeMatcher, error) {
 	m.addFilters(filters)
 	m.addTypedFilters(PointTable, filters)
 	tables, err := m.tables(PointTable)
-	return &tagMatcher{
-		mappings:   mappings,
-		filters:    filters,
-		tables:     tables,
-		matchAreas: false,
-	}, err
+	return newTagMatcher(mappings, tables, filters, nil, false, m.Conf.StopOnFirstMatch), err
 }
 
 func (m *Mapping) lineStringMatcher() (WayMatcher, error) {
@@ -27,12 +22,7 @@ func (m *Mapping) lineStringMatcher() (WayMatcher, error) {
 	m.addFilters(filters)
 	m.addTypedFilters(LineStringTable, filters)
 	tables, err := m.tables(LineStringTable)
-	return &tagMatcher{
-		mappings:   mappings,
-		filters:    filters,
-		tables:     tables,
-		matchAreas: false,
-	}, err
+	return newTagMatcher(mappings, tables, filters, nil, false, m.Conf.StopOnFirstMatch), err
 }
 
 func (m *Mapping) polygonMatcher() (RelWayMatcher, error) {
@@ -44,13 +34,7 @@ func (m *Mapping) polygonMatcher() (RelWayMatcher, error) {
 	relFilters := make(tableElementFilters)
 	m.addRelationFilters(PolygonTable, relFilters)
 	tables, err := m.tables(PolygonTable)
-	return &tagMatcher{
-		mappings:   mappings,
-		filters:    filters,
-		tables:     tables,
-		relFilters: relFilters,
-		matchAreas: true,
-	}, err
+	return newTagMatcher(mappings, tables, filters, relFilters, true, m.Conf.StopOnFirstMatch), err
 }
 
 func (m *Mapping) relationMatcher() (RelationMatcher, error) {
@@ -63,13 +47,7 @@ func (m *Mapping) relationMatcher() (RelationMatcher, error) {
 	relFilters := make(tableElementFilters)
 	m.addRelationFilters(RelationTable, relFilters)
 	tables, err := m.tables(RelationTable)
-	return &tagMatcher{
-		mappings:   mappings,
-		filters:    filters,
-		tables:     tables,
-		relFilters: relFilters,
-		matchAreas: true,
-	}, err
+	return newTagMatcher(mappings, tables, filters, relFilters, true, m.Conf.StopOnFirstMatch), err
 }
 
 func (m *Mapping) relationMemberMatcher() (RelationMatcher, error) {
@@ -81,13 +59,7 @@ func (m *Mapping) relationMemberMatcher() (RelationMatcher, error) {
 	relFilters := make(tableElementFilters)
 	m.addRelationFilters(RelationMemberTable, relFilters)
 	tables, err := m.tables(RelationMemberTable)
-	return &tagMatcher{
-		mappings:   mappings,
-		filters:    filters,
-		tables:     tables,
-		relFilters: relFilters,
-		matchAreas: true,
-	}, err
+	return newTagMatcher(mappings, tables, filters, relFilters, true, m.Conf.StopOnFirstMatch), err
 }
 
 type NodeMatcher interface {
@@ -114,10 +86,44 @@ type Match struct {
 	builder *rowBuilder
 }
 
+// Row returns the column values for elem. Returns nil if a not_null
+// column has no value and no default.
 func (m *Match) Row(elem *osm.Element, geom *geom.Geometry) []interface{} {
 	return m.builder.MakeRow(elem, geom, *m)
 }
 
+// AcceptGeometry returns false if the geometry is rejected by one of
+// the geometry filters (e.g. min_area) of the matched table.
+func (m *Match) AcceptGeometry(geom *geom.Geometry) bool {
+	if m.builder == nil {
+		return true
+	}
+	for _, filter := range m.builder.geomFilters {
+		if !filter(geom) {
+			return false
+		}
+	}
+	return true
+}
+
+// CentroidMatch returns the Match for the centroid table of a polygon
+// table with centroids. Rows of the centroid match have the same columns
+// as the polygon table. Returns false if the table has no centroids.
+func (m *Match) CentroidMatch() (Match, bool) {
+	if m.builder == nil || m.builder.centroid == nil {
+		return Match{}, false
+	}
+	return Match{
+		Key:   m.Key,
+		Value: m.Value,
+		Table: DestTable{
+			Name:       CentroidTableName(m.Table.Name),
+			SubMapping: m.Table.SubMapping,
+		},
+		builder: m.builder.centroid,
+	}, true
+}
+
 func (m *Match) MemberRow(rel *osm.Relation, member *osm.Member, memberIndex int, geom *geom.Geometry) []interface{} {
 	return m.builder.MakeMemberRow(rel, member, memberIndex, geom, *m)
 }
@@ -128,6 +134,112 @@ type tagMatcher struct {
 	filters    tableElementFilters
 	relFilters tableElementFilters
 	matchAreas bool
+	// stopOnFirst only returns the match with the lowest order
+	stopOnFirst bool
+
+	// compiled form of the mappings with indices into destTables,
+	// so that match does not need to build a map for each element
+	destTables   []DestTable
+	builders     []*rowBuilder
+	tblFilters   [][]elementFilter
+	tblRelFilter [][]elementFilter
+	dests        map[string]map[string][]compiledDest
+}
+
+type compiledDest struct {
+	table int
+	order int
+}
+
+// matchScratch collects the best match (lowest order) for each
+// destination table of a single element.
+type matchScratch struct {
+	matches []tableMatch
+	touched []int
+}
+
+type tableMatch struct {
+	key   string
+	value string
+	order int
+	used  bool
+}
+
+func newTagMatcher(
+	mappings TagTableMapping,
+	tables map[string]*rowBuilder,
+	filters tableElementFilters,
+	relFilters tableElementFilters,
+	matchAreas bool,
+	stopOnFirst bool,
+) *tagMatcher {
+	tm := &tagMatcher{
+		mappings:    mappings,
+		tables:      tables,
+		filters:     filters,
+		relFilters:  relFilters,
+		matchAreas:  matchAreas,
+		stopOnFirst: stopOnFirst,
+	}
+	tm.compile()
+	return tm
+}
+
+func (tm *tagMatcher) compile() {
+	tableIdx := make(map[DestTable]int)
+	tm.dests = make(map[string]map[string][]compiledDest)
+	for k, values := range tm.mappings {
+		tm.dests[string(k)] = make(map[string][]compiledDest)
+		for v, tbls := range values {
+			dests := make([]compiledDest, 0, len(tbls))
+			for _, t := range tbls {
+				idx, ok := tableIdx[t.DestTable]
+				if !ok {
+					idx = len(tm.destTables)
+					tableIdx[t.DestTable] = idx
+					tm.destTables = append(tm.destTables, t.DestTable)
+					tm.builders = append(tm.builders, tm.tables[t.Name])
+					tm.tblFilters = append(tm.tblFilters, tm.filters[t.Name])
+					tm.tblRelFilter = append(tm.tblRelFilter, tm.relFilters[t.Name])
+				}
+				dests = append(dests, compiledDest{table: idx, order: t.order})
+			}
+			tm.dests[string(k)][string(v)] = dests
+		}
+	}
+}
+
+func (s *matchScratch) add(k, v string, dests []compiledDest) {
+	for _, d := range dests {
+		m := &s.matches[d.table]
+		if m.used {
+			if m.order <= d.order {
+				continue
+			}
+		} else {
+			s.touched = append(s.touched, d.table)
+		}
+		*m = tableMatch{key: k, value: v, order: d.order, used: true}
+	}
+}
+
+// keepFirst only keeps the match with the lowest order of the first n
+// touched tables. Matches with the same order are sorted by table name.
+func (s *matchScratch) keepFirst(n int, tables []DestTable) int {
+	first := s.touched[0]
+	for _, idx := range s.touched[1:n] {
+		m, f := &s.matches[idx], &s.matches[first]
+		if m.order < f.order || (m.order == f.order && tables[idx].Name < tables[first].Name) {
+			first = idx
+		}
+	}
+	for _, idx := range s.touched[:n] {
+		if idx != first {
+			s.matches[idx].used = false
+		}
+	}
+	s.touched[0] = first
+	return 1
 }
 
 func (tm *tagMatcher) MatchNode(node *osm.Node) []Match {
@@ -158,83 +270,92 @@ func (tm *tagMatcher) MatchRelation(rel *osm.Relation) []Match {
 	return tm.match(rel.Tags, true, true)
 }
 
-type orderedMatch struct {
-	Match
-	order int
-}
-
 func (tm *tagMatcher) match(tags osm.Tags, closed bool, relation bool) []Match {
-	tables := make(map[DestTable]orderedMatch)
-
-	addTables := func(k, v string, tbls []orderedDestTable) {
-		for _, t := range tbls {
-			this := orderedMatch{
-				Match: Match{
-					Key:     k,
-					Value:   v,
-					Table:   t.DestTable,
-					builder: tm.tables[t.Name],
-				},
-				order: t.order,
-			}
-			if other, ok := tables[t.DestTable]; ok {
-				if other.order < this.order {
-					this = other
-				}
-			}
-			tables[t.DestTable] = this
-		}
+	s := &matchScratch{
+		matches: make([]tableMatch, len(tm.destTables)),
+		touched: make([]int, 0, len(tm.destTables)),
 	}
 
-	if values, ok := tm.mappings[Key("__any__")]; ok {
-		addTables("__any__", "__any__", values["__any__"])
+	if values, ok := tm.dests["__any__"]; ok {
+		s.add("__any__", "__any__", values["__any__"])
 	}
 
 	for k, v := range tags {
-		values, ok := tm.mappings[Key(k)]
+		values, ok := tm.dests[k]
 		if ok {
-			if tbls, ok := values["__any__"]; ok {
-				addTables(k, v, tbls)
+			if dests, ok := values["__any__"]; ok {
+				s.add(k, v, dests)
 			}
-			if tbls, ok := values[Value(v)]; ok {
-				addTables(k, v, tbls)
+			if dests, ok := values[v]; ok {
+				s.add(k, v, dests)
 			}
 		}
 	}
-	var matches []Match
-	for t, match := range tables {
-		filters, ok := tm.filters[t.Name]
-		filteredOut := false
-		if ok {
-			for _, filter := range filters {
-				if !filter(tags, Key(match.Key), closed) {
-					filteredOut = true
-					break
-				}
-			}
+
+	// remove filtered tables from touched
+	n := 0
+	for _, idx := range s.touched {
+		if tm.accept(idx, tags, Key(s.matches[idx].key), closed, relation) {
+			s.touched[n] = idx
+			n++
+		} else {
+			s.matches[idx].used = false
 		}
-		if relation && !filteredOut {
-			filters, ok := tm.relFilters[t.Name]
-			if ok {
-				for _, filter := range filters {
-					if !filter(tags, Key(match.Key), closed) {
-						filteredOut = true
-						break
-					}
-				}
+	}
+
+	if tm.stopOnFirst && n > 1 {
+		n = s.keepFirst(n, tm.destTables)
+	}
+
+	var matches []Match
+	if n > 0 {
+		matches = make([]Match, n)
+		for i, idx := range s.touched[:n] {
+			m := &s.matches[idx]
+			matches[i] = Match{
+				Key:     m.key,
+				Value:   m.value,
+				Table:   tm.destTables[idx],
+				builder: tm.builders[idx],
 			}
 		}
+	}
+	return matches
+}
 
-		if !filteredOut {
-			matches = append(matches, match.Match)
+func (tm *tagMatcher) accept(idx int, tags osm.Tags, key Key, closed bool, relation bool) bool {
+	for _, filter := range tm.tblFilters[idx] {
+		if !filter(tags, key, closed) {
+			return false
 		}
 	}
-	return matches
+	if relation {
+		for _, filter := range tm.tblRelFilter[idx] {
+			if !filter(tags, key, closed) {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 type valueBuilder struct {
-	key     Key
-	colType ColumnType
+	key          Key
+	colType      ColumnType
+	notNull      bool
+	defaultValue interface{}
+}
+
+// checkNull returns the default value for null values of not_null columns.
+// Returns false if the value is null and there is no default.
+func (v *valueBuilder) checkNull(value interface{}) (interface{}, bool) {
+	if value != nil || !v.notNull {
+		return value, true
+	}
+	if v.defaultValue != nil {
+		return v.defaultValue, true
+	}
+	return nil, false
 }
 
 func (v *valueBuilder) Value(elem *osm.Element, geom *geom.Geometry, match Match) interface{} {
@@ -261,13 +382,21 @@ func (v *valueBuilder) MemberValue(rel *osm.Relation, member *osm.Member, member
 }
 
 type rowBuilder struct {
-	columns []valueBuilder
+	columns     []valueBuilder
+	geomFilters []geometryFilter
+	// centroid builds rows for the centroid table, nil if the
+	// table has no centroids
+	centroid *rowBuilder
 }
 
 func (r *rowBuilder) MakeRow(elem *osm.Element, geom *geom.Geometry, match Match) []interface{} {
 	var row []interface{}
 	for _, column := range r.columns {
-		row = append(row, column.Value(elem, geom, match))
+		value, ok := column.checkNull(column.Value(elem, geom, match))
+		if !ok {
+			return nil
+		}
+		row = append(row, value)
 	}
 	return row
 }
@@ -275,7 +404,11 @@ func (r *rowBuilder) MakeRow(elem *osm.Element, geom *geom.Geometry, match Match
 func (r *rowBuilder) MakeMemberRow(rel *osm.Relation, member *osm.Member, memberIndex int, geom *geom.Geometry, match Match) []interface{} {
 	var row []interface{}
 	for _, column := range r.columns {
-		row = append(row, column.MemberValue(rel, member, memberIndex, geom, match))
+		value, ok := column.checkNull(column.MemberValue(rel, member, memberIndex, geom, match))
+		if !ok {
+			return nil
+		}
+		row = append(row, value)
 	}
 	return row
 }
@@ -20,3 +20,376 @@ func BenchmarkTagMatch(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkTagMatchCompiled benchmarks the compiled matching without
+// building the element in the loop.
+func BenchmarkTagMatchCompiled(b *testing.B) {
+	m, err := FromFile("test_mapping.yml")
+	if err != nil {
+		b.Fatal(err)
+	}
+	matcher := m.PolygonMatcher
+	e := osm.Relation{}
+	e.Tags = osm.Tags{"type": "multipolygon", "landuse": "forest", "name": "Forest", "source": "bling", "tourism": "zoo"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if m := matcher.MatchRelation(&e); len(m) != 1 {
+			b.Fatal(m)
+		}
+	}
+}
+
+// BenchmarkTagMatchMaps benchmarks the previous map based matching for comparison.
+func BenchmarkTagMatchMaps(b *testing.B) {
+	m, err := FromFile("test_mapping.yml")
+	if err != nil {
+		b.Fatal(err)
+	}
+	matcher := m.PolygonMatcher.(*tagMatcher)
+	tags := osm.Tags{"type": "multipolygon", "landuse": "forest", "name": "Forest", "source": "bling", "tourism": "zoo"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if m := matchWithMaps(matcher, tags, true, true); len(m) != 1 {
+			b.Fatal(m)
+		}
+	}
+}
+
+// matchWithMaps is the map based implementation of tagMatcher.match.
+func matchWithMaps(tm *tagMatcher, tags osm.Tags, closed bool, relation bool) []Match {
+	type orderedMatch struct {
+		Match
+		order int
+	}
+	tables := make(map[DestTable]orderedMatch)
+
+	addTables := func(k, v string, tbls []orderedDestTable) {
+		for _, t := range tbls {
+			this := orderedMatch{
+				Match: Match{
+					Key:     k,
+					Value:   v,
+					Table:   t.DestTable,
+					builder: tm.tables[t.Name],
+				},
+				order: t.order,
+			}
+			if other, ok := tables[t.DestTable]; ok {
+				if other.order < this.order {
+					this = other
+				}
+			}
+			tables[t.DestTable] = this
+		}
+	}
+
+	if values, ok := tm.mappings[Key("__any__")]; ok {
+		addTables("__any__", "__any__", values["__any__"])
+	}
+
+	for k, v := range tags {
+		values, ok := tm.mappings[Key(k)]
+		if ok {
+			if tbls, ok := values["__any__"]; ok {
+				addTables(k, v, tbls)
+			}
+			if tbls, ok := values[Value(v)]; ok {
+				addTables(k, v, tbls)
+			}
+		}
+	}
+	var matches []Match
+	for t, match := range tables {
+		filteredOut := false
+		for _, filter := range tm.filters[t.Name] {
+			if !filter(tags, Key(match.Key), closed) {
+				filteredOut = true
+				break
+			}
+		}
+		if relation && !filteredOut {
+			for _, filter := range tm.relFilters[t.Name] {
+				if !filter(tags, Key(match.Key), closed) {
+					filteredOut = true
+					break
+				}
+			}
+		}
+		if !filteredOut {
+			matches = append(matches, match.Match)
+		}
+	}
+	return matches
+}
+
+func TestTagMatchCompiled(t *testing.T) {
+	m, err := FromFile("test_mapping.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tags := range []osm.Tags{
+		{},
+		{"name": "foo"},
+		{"landuse": "forest", "name": "Forest"},
+		{"type": "multipolygon", "landuse": "forest", "tourism": "zoo"},
+		{"highway": "pedestrian", "area": "yes", "landuse": "park"},
+		{"building": "yes", "amenity": "school", "leisure": "park"},
+		{"natural": "water", "waterway": "riverbank"},
+	} {
+		for _, tm := range []*tagMatcher{
+			m.PointMatcher.(*tagMatcher),
+			m.LineStringMatcher.(*tagMatcher),
+			m.PolygonMatcher.(*tagMatcher),
+		} {
+			for _, relation := range []bool{false, true} {
+				expected := matchWithMaps(tm, tags, true, relation)
+				actual := tm.match(tags, true, relation)
+				if !matchesEqual(expected, actual) {
+					t.Errorf("unexpected matches for %v: %v != %v", tags, actual, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestStopOnFirstMatch(t *testing.T) {
+	conf := `
+    tables:
+      roads:
+        type: linestring
+        mapping:
+          highway: [motorway, primary]
+      main_roads:
+        type: linestring
+        mapping:
+          highway: [primary]
+    `
+	m, err := New([]byte(conf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	elem := osm.Way{}
+	elem.Tags = osm.Tags{"highway": "primary"}
+	if matches := m.LineStringMatcher.MatchWay(&elem); len(matches) != 2 {
+		t.Fatal("unexpected matches", matches)
+	}
+
+	m, err = New([]byte("\n    stop_on_first_match: true" + conf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := m.LineStringMatcher.MatchWay(&elem)
+	if len(matches) != 1 || matches[0].Table.Name != "main_roads" {
+		t.Error("unexpected matches", matches)
+	}
+
+	// same order, sorted by table name
+	elem.Tags = osm.Tags{"highway": "motorway", "railway": "rail"}
+	m, err = New([]byte(`
+    stop_on_first_match: true
+    tables:
+      roads:
+        type: linestring
+        mapping:
+          highway: [motorway]
+      railways:
+        type: linestring
+        mapping:
+          railway: [rail]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches = m.LineStringMatcher.MatchWay(&elem)
+	if len(matches) != 1 || matches[0].Table.Name != "railways" {
+		t.Error("unexpected matches", matches)
+	}
+}
+
+func TestCentroidMatch(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      buildings:
+        type: polygon
+        centroids: true
+        mapping:
+          building: [__any__]
+      landusages:
+        type: polygon
+        mapping:
+          landuse: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elem := osm.Way{Refs: []int64{1, 2, 3, 1}}
+	elem.Tags = osm.Tags{"building": "yes", "landuse": "forest"}
+	for _, match := range m.PolygonMatcher.MatchWay(&elem) {
+		cm, ok := match.CentroidMatch()
+		switch match.Table.Name {
+		case "buildings":
+			if !ok || cm.Table.Name != "buildings_point" || cm.Key != "building" {
+				t.Error("unexpected centroid match", cm, ok)
+			}
+		case "landusages":
+			if ok {
+				t.Error("unexpected centroid match for landusages", cm)
+			}
+		}
+	}
+
+	for _, conf := range []string{`
+    tables:
+      roads:
+        type: linestring
+        centroids: true
+        mapping:
+          highway: [__any__]
+    `, `
+    tables:
+      buildings:
+        type: polygon
+        centroids: true
+        mapping:
+          building: [__any__]
+      buildings_point:
+        type: point
+        mapping:
+          building: [__any__]
+    `} {
+		if _, err := New([]byte(conf)); err == nil {
+			t.Error("invalid centroids config not rejected", conf)
+		}
+	}
+}
+
+func TestNotNullColumns(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        columns:
+        - {name: osm_id, type: id}
+        - {name: layer, key: layer, type: integer, not_null: true, default: 0}
+        - {name: lanes, key: lanes, type: integer, not_null: true}
+        mapping:
+          highway: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elem := osm.Way{}
+	elem.ID = 1
+	elem.Tags = osm.Tags{"highway": "primary", "lanes": "2"}
+	matches := m.LineStringMatcher.MatchWay(&elem)
+	if len(matches) != 1 {
+		t.Fatal(matches)
+	}
+	row := matches[0].Row(&elem.Element, nil)
+	if len(row) != 3 || row[1] != 0 || row[2] != int64(2) {
+		t.Error("unexpected row", row)
+	}
+
+	elem.Tags = osm.Tags{"highway": "primary"}
+	if row := matches[0].Row(&elem.Element, nil); row != nil {
+		t.Error("row without not_null value not skipped", row)
+	}
+
+	_, err = New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        columns:
+        - {name: layer, key: layer, type: integer, default: 0}
+        mapping:
+          highway: [__any__]
+    `))
+	if err == nil {
+		t.Error("default without not_null not rejected")
+	}
+}
+
+func TestColumnsWithSameKey(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      buildings:
+        type: polygon
+        columns:
+        - {name: housenumber, key: "addr:housenumber", type: string}
+        - {name: housenumber_int, key: "addr:housenumber", type: integer, parse: int}
+        mapping:
+          building: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elem := osm.Way{Refs: []int64{1, 2, 3, 1}}
+	elem.Tags = osm.Tags{"building": "yes", "addr:housenumber": "12a"}
+	matches := m.PolygonMatcher.MatchWay(&elem)
+	if len(matches) != 1 {
+		t.Fatal(matches)
+	}
+	row := matches[0].Row(&elem.Element, nil)
+	if len(row) != 2 || row[0] != "12a" || row[1] != int64(12) {
+		t.Error("unexpected row", row)
+	}
+
+	_, err = New([]byte(`
+    tables:
+      buildings:
+        type: polygon
+        columns:
+        - {name: housenumber, key: "addr:housenumber", type: string, parse: int}
+        mapping:
+          building: [__any__]
+    `))
+	if err == nil {
+		t.Error("parse for string column not rejected")
+	}
+}
+
+func TestNormalizeColumns(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        columns:
+        - {name: name, key: name, type: string, normalize: trim}
+        - {name: ref, key: ref, type: string, normalize: upper}
+        - {name: lanes, key: lanes, type: integer, normalize: lower}
+        mapping:
+          highway: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elem := osm.Way{}
+	elem.Tags = osm.Tags{"highway": "primary", "name": "  Main Street ", "ref": "a 1", "lanes": "2"}
+	matches := m.LineStringMatcher.MatchWay(&elem)
+	if len(matches) != 1 {
+		t.Fatal(matches)
+	}
+	row := matches[0].Row(&elem.Element, nil)
+	if len(row) != 3 || row[0] != "Main Street" || row[1] != "A 1" || row[2] != int64(2) {
+		t.Error("unexpected row", row)
+	}
+
+	_, err = New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        columns:
+        - {name: name, key: name, type: string, normalize: title}
+        mapping:
+          highway: [__any__]
+    `))
+	if err == nil {
+		t.Error("unknown normalize not rejected")
+	}
+}
@@ -0,0 +1,177 @@
+package mapping
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/omniscale/imposm3/mapping/config"
+)
+
+type SchemaChangeKind string
+
+const (
+	TableAdded        SchemaChangeKind = "table added"
+	TableRemoved      SchemaChangeKind = "table removed"
+	TableTypeChanged  SchemaChangeKind = "table type changed"
+	ColumnAdded       SchemaChangeKind = "column added"
+	ColumnRemoved     SchemaChangeKind = "column removed"
+	ColumnTypeChanged SchemaChangeKind = "column type changed"
+)
+
+// SchemaChange is a difference between the tables of two mappings.
+// OldType and NewType are the table or column types for type changes.
+type SchemaChange struct {
+	Kind    SchemaChangeKind
+	Table   string
+	Column  string
+	OldType string
+	NewType string
+}
+
+func (c SchemaChange) String() string {
+	name := c.Table
+	if c.Column != "" {
+		name += "." + c.Column
+	}
+	if c.OldType != "" || c.NewType != "" {
+		return fmt.Sprintf("%s %s: %s -> %s", c.Kind, name, c.OldType, c.NewType)
+	}
+	return fmt.Sprintf("%s %s", c.Kind, name)
+}
+
+// Diff returns all changes of the tables and columns from m to other.
+// Changes of the mappings or filters are not reported, as they do not
+// change the database schema. Changes are sorted by table name.
+func (m *Mapping) Diff(other *Mapping) []SchemaChange {
+	var changes []SchemaChange
+
+	for _, name := range sortedTableNames(m.Conf.Tables, other.Conf.Tables) {
+		old, inOld := m.Conf.Tables[name]
+		new, inNew := other.Conf.Tables[name]
+		if !inNew {
+			changes = append(changes, SchemaChange{Kind: TableRemoved, Table: name})
+			continue
+		}
+		if !inOld {
+			changes = append(changes, SchemaChange{Kind: TableAdded, Table: name})
+			continue
+		}
+		if old.Type != new.Type {
+			changes = append(changes, SchemaChange{
+				Kind: TableTypeChanged, Table: name,
+				OldType: old.Type, NewType: new.Type,
+			})
+		}
+		changes = append(changes, diffColumns(name, old.Columns, new.Columns)...)
+		if old.Centroids && !new.Centroids {
+			changes = append(changes, SchemaChange{Kind: TableRemoved, Table: CentroidTableName(name)})
+		} else if !old.Centroids && new.Centroids {
+			changes = append(changes, SchemaChange{Kind: TableAdded, Table: CentroidTableName(name)})
+		}
+	}
+	return changes
+}
+
+func diffColumns(table string, old, new []*config.Column) []SchemaChange {
+	var changes []SchemaChange
+	newColumns := make(map[string]*config.Column, len(new))
+	for _, c := range new {
+		newColumns[c.Name] = c
+	}
+	oldColumns := make(map[string]*config.Column, len(old))
+	for _, c := range old {
+		oldColumns[c.Name] = c
+		n, ok := newColumns[c.Name]
+		if !ok {
+			changes = append(changes, SchemaChange{Kind: ColumnRemoved, Table: table, Column: c.Name})
+		} else if n.Type != c.Type {
+			changes = append(changes, SchemaChange{
+				Kind: ColumnTypeChanged, Table: table, Column: c.Name,
+				OldType: c.Type, NewType: n.Type,
+			})
+		}
+	}
+	for _, c := range new {
+		if _, ok := oldColumns[c.Name]; !ok {
+			changes = append(changes, SchemaChange{Kind: ColumnAdded, Table: table, Column: c.Name})
+		}
+	}
+	return changes
+}
+
+func sortedTableNames(a, b config.Tables) []string {
+	names := make([]string, 0, len(a)+len(b))
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TablesByKey returns the sorted names of all tables that use a tag key,
+// for all keys used in the mappings, columns or filters of the tables.
+func (m *Mapping) TablesByKey() map[Key][]string {
+	tables := make(map[Key]map[string]struct{})
+	add := func(key Key, table string) {
+		if key == "" {
+			return
+		}
+		if _, ok := tables[key]; !ok {
+			tables[key] = make(map[string]struct{})
+		}
+		tables[key][table] = struct{}{}
+	}
+	addKeyValues := func(kv config.KeyValues, table string) {
+		for k := range kv {
+			add(Key(k), table)
+		}
+	}
+
+	for name, t := range m.Conf.Tables {
+		addKeyValues(t.Mapping, name)
+		for _, sub := range t.Mappings {
+			addKeyValues(sub.Mapping, name)
+		}
+		addKeyValues(t.TypeMappings.Points, name)
+		addKeyValues(t.TypeMappings.LineStrings, name)
+		addKeyValues(t.TypeMappings.Polygons, name)
+		for _, c := range t.Columns {
+			add(Key(c.Key), name)
+			for _, k := range c.Keys {
+				add(Key(k), name)
+			}
+		}
+		if f := t.Filters; f != nil {
+			addKeyValues(f.Require, name)
+			addKeyValues(f.Reject, name)
+			for k := range f.RequireRegexp {
+				add(Key(k), name)
+			}
+			for k := range f.RejectRegexp {
+				add(Key(k), name)
+			}
+			for _, k := range f.RequirePresent {
+				add(Key(k), name)
+			}
+			if f.ExcludeTags != nil {
+				for _, keyVal := range *f.ExcludeTags {
+					add(Key(keyVal[0]), name)
+				}
+			}
+		}
+	}
+
+	result := make(map[Key][]string, len(tables))
+	for key, names := range tables {
+		for name := range names {
+			result[key] = append(result[key], name)
+		}
+		sort.Strings(result[key])
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package mapping
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMappingDiff(t *testing.T) {
+	old, err := New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        columns:
+        - {name: osm_id, type: id}
+        - {name: name, key: name, type: string}
+        mapping:
+          highway: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        columns:
+        - {name: osm_id, type: id}
+        - {name: name, key: name, type: string}
+        - {name: oneway, key: oneway, type: direction}
+        mapping:
+          highway: [__any__, track]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := old.Diff(new)
+	expected := []SchemaChange{{Kind: ColumnAdded, Table: "roads", Column: "oneway"}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatal("unexpected changes", changes)
+	}
+	changes = new.Diff(old)
+	expected = []SchemaChange{{Kind: ColumnRemoved, Table: "roads", Column: "oneway"}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatal("unexpected changes", changes)
+	}
+	if changes := old.Diff(old); len(changes) != 0 {
+		t.Fatal("unexpected changes", changes)
+	}
+
+	other, err := New([]byte(`
+    tables:
+      roads:
+        type: polygon
+        columns:
+        - {name: osm_id, type: id}
+        - {name: name, key: name, type: integer}
+        mapping:
+          highway: [__any__]
+      buildings:
+        type: polygon
+        mapping:
+          building: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes = old.Diff(other)
+	expected = []SchemaChange{
+		{Kind: TableAdded, Table: "buildings"},
+		{Kind: TableTypeChanged, Table: "roads", OldType: "linestring", NewType: "polygon"},
+		{Kind: ColumnTypeChanged, Table: "roads", Column: "name", OldType: "string", NewType: "integer"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatal("unexpected changes", changes)
+	}
+	if s := changes[2].String(); s != "column type changed roads.name: string -> integer" {
+		t.Error("unexpected string", s)
+	}
+}
+
+func TestTablesByKey(t *testing.T) {
+	m, err := New([]byte(`
+    tables:
+      roads:
+        type: linestring
+        columns:
+        - {name: osm_id, type: id}
+        - {name: name, key: name, type: string}
+        mapping:
+          highway: [__any__]
+      areas:
+        type: polygon
+        columns:
+        - {name: name, key: name, type: string}
+        filters:
+          require:
+            area: ["yes"]
+        mappings:
+          pedestrian:
+            mapping:
+              highway: [pedestrian]
+      pois:
+        type: point
+        filters:
+          reject:
+            highway: [bus_stop]
+        mapping:
+          amenity: [__any__]
+      buildings:
+        type: polygon
+        mapping:
+          building: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byKey := m.TablesByKey()
+	if tables := byKey["highway"]; !reflect.DeepEqual(tables, []string{"areas", "pois", "roads"}) {
+		t.Error("unexpected tables for highway", tables)
+	}
+	if tables := byKey["name"]; !reflect.DeepEqual(tables, []string{"areas", "roads"}) {
+		t.Error("unexpected tables for name", tables)
+	}
+	if tables := byKey["building"]; !reflect.DeepEqual(tables, []string{"buildings"}) {
+		t.Error("unexpected tables for building", tables)
+	}
+	if _, ok := byKey[""]; ok {
+		t.Error("empty key for columns without key")
+	}
+}
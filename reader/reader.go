@@ -68,10 +68,11 @@ func ReadPbf(
 	}
 
 	config := pbf.Config{
-		Coords:    coords,
-		Nodes:     nodes,
-		Ways:      ways,
-		Relations: relations,
+		Coords:          coords,
+		Nodes:           nodes,
+		Ways:            ways,
+		Relations:       relations,
+		IncludeMetadata: tagmapping.UsesMetadata(),
 	}
 
 	// wait for all coords/nodes to be processed before continuing with
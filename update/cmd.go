@@ -114,7 +114,7 @@ func diffImportLoop(baseOpts config.Base, nextSeq <-chan replication.Sequence) e
 		tilelist = expire.NewTileList(baseOpts.ExpireTilesZoom, baseOpts.ExpireTilesDir)
 	}
 
-	tagmapping, err := mapping.FromFile(baseOpts.MappingFile)
+	tagmapping, err := mapping.FromFileWithSrid(baseOpts.MappingFile, baseOpts.Srid)
 	if err != nil {
 		log.Fatalf("[fatal] reading tagmapping: %v", err)
 	}
@@ -32,7 +32,8 @@ func importDiffFile(
 ) error {
 	diffs := make(chan osm.Diff)
 	config := diff.Config{
-		Diffs: diffs,
+		Diffs:           diffs,
+		IncludeMetadata: tagmapping.UsesMetadata(),
 	}
 
 	f, err := os.Open(oscFile)
@@ -79,6 +80,11 @@ func importDiffFile(
 		tagmapping.RelationMemberMatcher,
 		srid)
 	relWriter.SetLimiter(geometryLimiter)
+	relWriter.SetBBox(tagmapping.BBox)
+	relWriter.SetGridSize(tagmapping.GridSize)
+	relWriter.SetSnapNodes(tagmapping.SnapNodes)
+	relWriter.SetMaxVertices(tagmapping.MaxVertices)
+	relWriter.SetInvalidPolicy(tagmapping.InvalidPolicy)
 	relWriter.SetExpireor(expireor)
 	relWriter.Start()
 
@@ -90,6 +96,12 @@ func importDiffFile(
 		tagmapping.LineStringMatcher,
 		srid)
 	wayWriter.SetLimiter(geometryLimiter)
+	wayWriter.SetBBox(tagmapping.BBox)
+	wayWriter.SetGridSize(tagmapping.GridSize)
+	wayWriter.SetSnapNodes(tagmapping.SnapNodes)
+	wayWriter.SetMaxVertices(tagmapping.MaxVertices)
+	wayWriter.SetRejectZeroArea(tagmapping.RejectZeroArea)
+	wayWriter.SetInvalidPolicy(tagmapping.InvalidPolicy)
 	wayWriter.SetExpireor(expireor)
 	wayWriter.Start()
 
@@ -98,6 +110,8 @@ func importDiffFile(
 		tagmapping.PointMatcher,
 		srid)
 	nodeWriter.SetLimiter(geometryLimiter)
+	nodeWriter.SetBBox(tagmapping.BBox)
+	nodeWriter.SetGridSize(tagmapping.GridSize)
 	nodeWriter.SetExpireor(expireor)
 	nodeWriter.Start()
 
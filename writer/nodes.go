@@ -51,8 +51,14 @@ func (nw *NodeWriter) loop() {
 	for n := range nw.nodes {
 		nw.progress.AddNodes(1)
 		if matches := nw.pointMatcher.MatchNode(n); len(matches) > 0 {
+			if nw.outsideBBox(geomp.NodesBounds([]osm.Node{*n})) {
+				continue
+			}
 			nw.NodeToSrid(n)
 			point, err := geomp.Point(geos, *n)
+			if err == nil {
+				point, err = nw.reducePrecision(geos, point)
+			}
 			if err != nil {
 				if errl, ok := err.(ErrorLevel); !ok || errl.Level() > 0 {
 					log.Println("[warn]: ", err)
@@ -60,11 +66,7 @@ func (nw *NodeWriter) loop() {
 				continue
 			}
 
-			geom, err := geomp.AsGeomElement(geos, point)
-			if err != nil {
-				log.Println("[warn]: ", err)
-				continue
-			}
+			geom := geomp.LazyGeomElement(geos, point)
 
 			inserted := false
 			if nw.limiter != nil {
@@ -2,6 +2,7 @@ package writer
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	osm "github.com/omniscale/go-osm"
@@ -84,6 +85,7 @@ NextRel:
 			}
 			continue
 		}
+		bounds := geosp.NilBounds
 		for i, m := range r.Members {
 			if m.Way == nil {
 				continue
@@ -95,10 +97,19 @@ NextRel:
 				}
 				continue NextRel
 			}
+			if rw.bbox != nil {
+				bounds = bounds.Extend(geomp.NodesBounds(m.Way.Nodes))
+			}
 			rw.NodesToSrid(m.Way.Nodes)
+			rw.snapWayNodes(m.Way.Nodes)
 			r.Members[i].Element = &m.Way.Element
 		}
 
+		// relations without ways are not filtered
+		if bounds != geosp.NilBounds && rw.outsideBBox(bounds) {
+			continue
+		}
+
 		// handleRelation updates r.Members but we need all of them
 		// for the diffCache
 		allMembers := r.Members
@@ -149,6 +160,9 @@ func handleMultiPolygon(rw *RelationWriter, r *osm.Relation, geos *geosp.Geos) b
 		}
 		return false
 	}
+	if rw.invalidPolicy == mapping.InvalidKeep || rw.invalidPolicy == mapping.InvalidSkip {
+		prepedRel.KeepInvalid()
+	}
 
 	// build the multipolygon
 	geom, err := prepedRel.Build()
@@ -161,6 +175,26 @@ func handleMultiPolygon(rw *RelationWriter, r *osm.Relation, geos *geosp.Geos) b
 		}
 		return false
 	}
+	if rw.invalidPolicy == mapping.InvalidSkip && !geos.IsValid(geom.Geom) {
+		atomic.AddInt64(&geomp.BuildStats.Invalid, 1)
+		return false
+	}
+	if rw.gridSize != 0 {
+		reduced, err := rw.reducePrecision(geos, geom.Geom)
+		if err != nil {
+			log.Println("[warn]: ", err)
+			return false
+		}
+		geom = geomp.LazyGeomElement(geos, reduced)
+	}
+	if rw.maxVertices != 0 {
+		limited, err := rw.limitVertices(geos, r.Element, geom.Geom)
+		if err != nil {
+			log.Println("[warn]: ", err)
+			return false
+		}
+		geom = geomp.LazyGeomElement(geos, limited)
+	}
 
 	if rw.limiter != nil {
 		start := time.Now()
@@ -178,8 +212,8 @@ func handleMultiPolygon(rw *RelationWriter, r *osm.Relation, geos *geosp.Geos) b
 		for _, g := range parts {
 			rel := osm.Relation(*r)
 			rel.ID = rw.relID(r.ID)
-			geom = geomp.Geometry{Geom: g, Wkb: geos.AsEwkbHex(g)}
-			err := rw.inserter.InsertPolygon(rel.Element, geom, matches)
+			geom = geomp.LazyGeomElement(geos, g)
+			err := rw.insertPolygon(geos, rel.Element, geom, matches)
 			if err != nil {
 				if errl, ok := err.(ErrorLevel); !ok || errl.Level() > 0 {
 					log.Println("[warn]: ", err)
@@ -190,7 +224,7 @@ func handleMultiPolygon(rw *RelationWriter, r *osm.Relation, geos *geosp.Geos) b
 	} else {
 		rel := osm.Relation(*r)
 		rel.ID = rw.relID(r.ID)
-		err := rw.inserter.InsertPolygon(rel.Element, geom, matches)
+		err := rw.insertPolygon(geos, rel.Element, geom, matches)
 		if err != nil {
 			if errl, ok := err.(ErrorLevel); !ok || errl.Level() > 0 {
 				log.Println("[warn]: ", err)
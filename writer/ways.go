@@ -84,7 +84,11 @@ func (ww *WayWriter) loop() {
 			if err != nil {
 				return false
 			}
+			if ww.outsideBBox(geomp.NodesBounds(w.Nodes)) {
+				return false
+			}
 			ww.NodesToSrid(w.Nodes)
+			ww.snapWayNodes(w.Nodes)
 			filled = true
 			return true
 		}
@@ -144,9 +148,13 @@ func (ww *WayWriter) buildAndInsert(
 	var geosgeom *geos.Geom
 
 	if isPolygon {
-		geosgeom, err = geomp.Polygon(g, way.Nodes)
+		if ww.rejectZeroArea {
+			geosgeom, err = geomp.NonZeroPolygon(g, way.Nodes)
+		} else {
+			geosgeom, err = geomp.Polygon(g, way.Nodes)
+		}
 		if err == nil {
-			geosgeom, err = g.MakeValid(geosgeom)
+			geosgeom, err = ww.validPolygon(g, geosgeom)
 		}
 	} else {
 		geosgeom, err = geomp.LineString(g, way.Nodes)
@@ -154,11 +162,20 @@ func (ww *WayWriter) buildAndInsert(
 	if err != nil {
 		return err, false
 	}
-
-	geom, err := geomp.AsGeomElement(g, geosgeom)
+	if geosgeom == nil {
+		// invalid polygon, skipped
+		return nil, false
+	}
+	geosgeom, err = ww.reducePrecision(g, geosgeom)
 	if err != nil {
 		return err, false
 	}
+	geosgeom, err = ww.limitVertices(g, way.Element, geosgeom)
+	if err != nil {
+		return err, false
+	}
+
+	geom := geomp.LazyGeomElement(g, geosgeom)
 
 	inserted := true
 	if ww.limiter != nil {
@@ -171,9 +188,9 @@ func (ww *WayWriter) buildAndInsert(
 			inserted = false
 		}
 		for _, p := range parts {
-			geom = geomp.Geometry{Geom: p, Wkb: g.AsEwkbHex(p)}
+			geom = geomp.LazyGeomElement(g, p)
 			if isPolygon {
-				if err := ww.inserter.InsertPolygon(way.Element, geom, matches); err != nil {
+				if err := ww.insertPolygon(g, way.Element, geom, matches); err != nil {
 					return err, false
 				}
 			} else {
@@ -184,7 +201,7 @@ func (ww *WayWriter) buildAndInsert(
 		}
 	} else {
 		if isPolygon {
-			if err := ww.inserter.InsertPolygon(way.Element, geom, matches); err != nil {
+			if err := ww.insertPolygon(g, way.Element, geom, matches); err != nil {
 				return err, false
 			}
 		} else {
@@ -1,14 +1,20 @@
 package writer
 
 import (
+	"errors"
 	"runtime"
 	"sync"
+	"sync/atomic"
 
 	osm "github.com/omniscale/go-osm"
 	"github.com/omniscale/imposm3/cache"
 	"github.com/omniscale/imposm3/database"
 	"github.com/omniscale/imposm3/expire"
+	geomp "github.com/omniscale/imposm3/geom"
+	"github.com/omniscale/imposm3/geom/geos"
 	"github.com/omniscale/imposm3/geom/limit"
+	"github.com/omniscale/imposm3/log"
+	"github.com/omniscale/imposm3/mapping"
 	"github.com/omniscale/imposm3/proj"
 	"github.com/omniscale/imposm3/stats"
 )
@@ -32,12 +38,57 @@ type OsmElemWriter struct {
 	srid       int
 	expireor   expire.Expireor
 	concurrent bool
+	// invalidPolicy for polygons, repairs invalid polygons if empty
+	invalidPolicy mapping.InvalidPolicy
+	// gridSize for rounding of all coordinates, 0 for no rounding
+	gridSize float64
+	// snapNodes rounds way nodes to gridSize before building geometries
+	snapNodes bool
+	// maxVertices of linestrings and polygons, 0 for no simplification
+	maxVertices int
+	// rejectZeroArea skips polygons without an area
+	rejectZeroArea bool
+	// bbox in EPSG:4326 for elements, nil for no filtering
+	bbox *geos.Bounds
 }
 
 func (writer *OsmElemWriter) SetLimiter(limiter *limit.Limiter) {
 	writer.limiter = limiter
 }
 
+func (writer *OsmElemWriter) SetGridSize(gridSize float64) {
+	writer.gridSize = gridSize
+}
+
+// SetSnapNodes enables rounding of way nodes to the grid size before the
+// geometries are built. Requires SetGridSize.
+func (writer *OsmElemWriter) SetSnapNodes(snap bool) {
+	writer.snapNodes = snap
+}
+
+// SetMaxVertices enables the simplification of linestrings and polygons
+// with more than maxVertices vertices.
+func (writer *OsmElemWriter) SetMaxVertices(maxVertices int) {
+	writer.maxVertices = maxVertices
+}
+
+// SetRejectZeroArea enables the rejection of polygons without an area
+// (e.g. all nodes are collinear). These polygons are skipped.
+func (writer *OsmElemWriter) SetRejectZeroArea(reject bool) {
+	writer.rejectZeroArea = reject
+}
+
+// SetBBox enables the filtering of elements that are completely outside
+// of bbox (in EPSG:4326). Unlike SetLimiter, elements are not clipped and
+// they are filtered before the geometries are built.
+func (writer *OsmElemWriter) SetBBox(bbox *geos.Bounds) {
+	writer.bbox = bbox
+}
+
+func (writer *OsmElemWriter) SetInvalidPolicy(policy mapping.InvalidPolicy) {
+	writer.invalidPolicy = policy
+}
+
 func (writer *OsmElemWriter) EnableConcurrent() {
 	writer.concurrent = true
 }
@@ -74,6 +125,20 @@ func (writer *OsmElemWriter) NodesToSrid(nodes []osm.Node) {
 	}
 }
 
+// outsideBBox returns true if the (unprojected) bounds are
+// completely outside of the bbox.
+func (writer *OsmElemWriter) outsideBBox(bounds geos.Bounds) bool {
+	return writer.bbox != nil && !writer.bbox.Intersects(bounds)
+}
+
+// snapWayNodes rounds the (projected) nodes to the grid size,
+// if enabled with SetSnapNodes.
+func (writer *OsmElemWriter) snapWayNodes(nodes []osm.Node) {
+	if writer.snapNodes {
+		geomp.SnapNodes(nodes, writer.gridSize)
+	}
+}
+
 func (writer *OsmElemWriter) NodeToSrid(node *osm.Node) {
 	if writer.srid == 4326 {
 		return
@@ -83,3 +148,79 @@ func (writer *OsmElemWriter) NodeToSrid(node *osm.Node) {
 	}
 	node.Long, node.Lat = proj.WgsToMerc(node.Long, node.Lat)
 }
+
+// insertPolygon inserts the polygon geom and the point on surface of geom
+// for all matches of tables with centroids.
+func (writer *OsmElemWriter) insertPolygon(g *geos.Geos, elem osm.Element, geom geomp.Geometry, matches []mapping.Match) error {
+	if err := writer.inserter.InsertPolygon(elem, geom, matches); err != nil {
+		return err
+	}
+	if geom.Geom == nil {
+		return nil
+	}
+	var centroidMatches []mapping.Match
+	for _, match := range matches {
+		if cm, ok := match.CentroidMatch(); ok && match.AcceptGeometry(&geom) {
+			centroidMatches = append(centroidMatches, cm)
+		}
+	}
+	if len(centroidMatches) == 0 {
+		return nil
+	}
+	point := g.PointOnSurface(geom.Geom)
+	if point == nil {
+		return errors.New("unable to create point on surface")
+	}
+	g.DestroyLater(point)
+	return writer.inserter.InsertPoint(elem, geomp.LazyGeomElement(g, point), centroidMatches)
+}
+
+// validPolygon handles invalid polygons according to the invalidPolicy.
+// Returns nil if the polygon should be skipped.
+func (writer *OsmElemWriter) validPolygon(g *geos.Geos, geom *geos.Geom) (*geos.Geom, error) {
+	switch writer.invalidPolicy {
+	case mapping.InvalidKeep:
+		return geom, nil
+	case mapping.InvalidSkip:
+		if !g.IsValid(geom) {
+			atomic.AddInt64(&geomp.BuildStats.Invalid, 1)
+			return nil, nil
+		}
+		return geom, nil
+	default:
+		return geomp.MakeValid(g, geom)
+	}
+}
+
+// reducePrecision rounds all coordinates of geom to the gridSize.
+// Returns geom if gridSize is not set.
+func (writer *OsmElemWriter) reducePrecision(g *geos.Geos, geom *geos.Geom) (*geos.Geom, error) {
+	if writer.gridSize == 0 {
+		return geom, nil
+	}
+	result, err := g.SetPrecision(geom, writer.gridSize)
+	if err != nil {
+		return nil, err
+	}
+	g.DestroyLater(result)
+	return result, nil
+}
+
+// limitVertices simplifies geom if it has more than maxVertices vertices.
+// Returns geom if maxVertices is not set or if geom has fewer vertices.
+func (writer *OsmElemWriter) limitVertices(g *geos.Geos, elem osm.Element, geom *geos.Geom) (*geos.Geom, error) {
+	if writer.maxVertices == 0 {
+		return geom, nil
+	}
+	n := int(g.NumCoordinates(geom))
+	if n <= writer.maxVertices {
+		return geom, nil
+	}
+	result := g.SimplifyToVertexCount(geom, writer.maxVertices)
+	if result == nil {
+		return nil, errors.New("unable to simplify geometry")
+	}
+	g.DestroyLater(result)
+	log.Printf("[warn]: simplified geometry of %d from %d to %d vertices", elem.ID, n, g.NumCoordinates(result))
+	return result, nil
+}
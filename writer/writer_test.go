@@ -0,0 +1,236 @@
+package writer
+
+import (
+	"math"
+	"testing"
+
+	osm "github.com/omniscale/go-osm"
+	geomp "github.com/omniscale/imposm3/geom"
+	"github.com/omniscale/imposm3/geom/geos"
+	"github.com/omniscale/imposm3/mapping"
+)
+
+type insertedRow struct {
+	table string
+	geom  geomp.Geometry
+}
+
+type recordingInserter struct {
+	rows []insertedRow
+}
+
+func (r *recordingInserter) insert(geom geomp.Geometry, matches []mapping.Match) error {
+	for _, m := range matches {
+		if m.AcceptGeometry(&geom) {
+			r.rows = append(r.rows, insertedRow{m.Table.Name, geom})
+		}
+	}
+	return nil
+}
+
+func (r *recordingInserter) InsertPoint(elem osm.Element, geom geomp.Geometry, matches []mapping.Match) error {
+	return r.insert(geom, matches)
+}
+func (r *recordingInserter) InsertLineString(elem osm.Element, geom geomp.Geometry, matches []mapping.Match) error {
+	return r.insert(geom, matches)
+}
+func (r *recordingInserter) InsertPolygon(elem osm.Element, geom geomp.Geometry, matches []mapping.Match) error {
+	return r.insert(geom, matches)
+}
+func (r *recordingInserter) InsertRelationMember(rel osm.Relation, m osm.Member, mi int, geom geomp.Geometry, matches []mapping.Match) error {
+	return nil
+}
+
+func TestInsertPolygonCentroids(t *testing.T) {
+	m, err := mapping.New([]byte(`
+    tables:
+      buildings:
+        type: polygon
+        centroids: true
+        mapping:
+          building: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	way := osm.Way{Refs: []int64{1, 2, 3, 4, 1}}
+	way.Tags = osm.Tags{"building": "yes"}
+	matches := m.PolygonMatcher.MatchWay(&way)
+
+	inserter := &recordingInserter{}
+	writer := OsmElemWriter{inserter: inserter}
+	// concave polygon, the centroid (3.5 3.5) is outside
+	polygon := g.FromWkt("POLYGON((0 0, 10 0, 10 2, 2 2, 2 10, 0 10, 0 0))")
+	if err := writer.insertPolygon(g, way.Element, geomp.LazyGeomElement(g, polygon), matches); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(inserter.rows) != 2 {
+		t.Fatal("expected polygon and point row", inserter.rows)
+	}
+	if inserter.rows[0].table != "buildings" || g.Type(inserter.rows[0].geom.Geom) != "Polygon" {
+		t.Error("unexpected polygon row", inserter.rows[0])
+	}
+	point := inserter.rows[1].geom
+	if inserter.rows[1].table != "buildings_point" || g.Type(point.Geom) != "Point" {
+		t.Error("unexpected point row", inserter.rows[1])
+	}
+	if !g.Contains(polygon, point.Geom) {
+		t.Error("point not inside of polygon", g.AsWkt(point.Geom))
+	}
+	if len(point.EwkbHex()) == 0 {
+		t.Error("missing WKB for point")
+	}
+}
+
+func TestValidPolygon(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	for _, tc := range []struct {
+		policy   mapping.InvalidPolicy
+		inserted bool
+		valid    bool
+	}{
+		{mapping.InvalidSkip, false, false},
+		{mapping.InvalidKeep, true, false},
+		{mapping.InvalidRepair, true, true},
+		{"", true, true},
+	} {
+		writer := OsmElemWriter{}
+		writer.SetInvalidPolicy(tc.policy)
+		bowtie := g.FromWkt("POLYGON((0 0, 10 10, 10 0, 0 10, 0 0))")
+		result, err := writer.validPolygon(g, bowtie)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (result != nil) != tc.inserted {
+			t.Errorf("unexpected result for %q: %v", tc.policy, result)
+			continue
+		}
+		if result != nil && g.IsValid(result) != tc.valid {
+			t.Errorf("unexpected validity for %q: %s", tc.policy, g.AsWkt(result))
+		}
+	}
+}
+
+func TestLimitVertices(t *testing.T) {
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	nodes := make([]osm.Node, 1000)
+	for i := range nodes {
+		nodes[i] = osm.Node{Long: float64(i), Lat: 100 * math.Sin(float64(i)/20)}
+	}
+	line, err := geomp.LineString(g, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer := OsmElemWriter{}
+	if result, err := writer.limitVertices(g, osm.Element{}, line); err != nil || result != line {
+		t.Fatal("geometry changed without max_vertices", result, err)
+	}
+
+	writer.SetMaxVertices(100)
+	result, err := writer.limitVertices(g, osm.Element{ID: 1}, line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := g.NumCoordinates(result); n > 100 || n < 50 {
+		t.Error("unexpected number of vertices", n)
+	}
+	if result.Length() < 0.9*line.Length() {
+		t.Error("unexpected length", result.Length(), line.Length())
+	}
+
+	small := g.FromWkt("LINESTRING(0 0, 10 0, 10 10)")
+	if result, err := writer.limitVertices(g, osm.Element{}, small); err != nil || result != small {
+		t.Error("small geometry changed", result, err)
+	}
+}
+
+func TestOutsideBBox(t *testing.T) {
+	m, err := mapping.New([]byte("bbox: [8, 53, 9, 54]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := OsmElemWriter{}
+	outside := []osm.Node{{Long: 10, Lat: 53.5}, {Long: 10.5, Lat: 53.5}}
+	inside := []osm.Node{{Long: 8.5, Lat: 53.5}, {Long: 8.6, Lat: 53.6}}
+	crossing := []osm.Node{{Long: 7.5, Lat: 53.5}, {Long: 9.5, Lat: 53.5}}
+
+	if writer.outsideBBox(geomp.NodesBounds(outside)) {
+		t.Error("way rejected without bbox")
+	}
+
+	writer.SetBBox(m.BBox)
+	if !writer.outsideBBox(geomp.NodesBounds(outside)) {
+		t.Error("way outside of bbox not rejected")
+	}
+	if writer.outsideBBox(geomp.NodesBounds(inside)) {
+		t.Error("way inside of bbox rejected")
+	}
+	if writer.outsideBBox(geomp.NodesBounds(crossing)) {
+		t.Error("way crossing bbox rejected")
+	}
+
+	if _, err := mapping.New([]byte("bbox: [9, 53, 8, 54]")); err == nil {
+		t.Error("invalid bbox not rejected")
+	}
+}
+
+func TestRejectZeroArea(t *testing.T) {
+	m, err := mapping.New([]byte(`
+    geometries:
+      reject_zero_area: true
+    tables:
+      landuse:
+        type: polygon
+        mapping:
+          landuse: [__any__]
+    `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := geos.NewGeos()
+	defer g.Finish()
+
+	way := osm.Way{
+		Element: osm.Element{Tags: osm.Tags{"landuse": "forest"}},
+		Refs:    []int64{1, 2, 3, 4, 1},
+		Nodes: []osm.Node{
+			{Element: osm.Element{ID: 1}, Long: 0, Lat: 0},
+			{Element: osm.Element{ID: 2}, Long: 5, Lat: 5},
+			{Element: osm.Element{ID: 3}, Long: 10, Lat: 10},
+			{Element: osm.Element{ID: 4}, Long: 5, Lat: 5},
+			{Element: osm.Element{ID: 1}, Long: 0, Lat: 0},
+		},
+	}
+	matches := m.PolygonMatcher.MatchWay(&way)
+
+	inserter := &recordingInserter{}
+	ww := WayWriter{OsmElemWriter: OsmElemWriter{inserter: inserter}}
+	ww.SetInvalidPolicy(mapping.InvalidKeep)
+	if err, _ := ww.buildAndInsert(g, &way, matches, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(inserter.rows) != 1 {
+		t.Fatal("zero area polygon not inserted without reject_zero_area", inserter.rows)
+	}
+
+	inserter.rows = nil
+	ww.SetRejectZeroArea(m.RejectZeroArea)
+	err, inserted := ww.buildAndInsert(g, &way, matches, true)
+	if err != geomp.ErrorZeroArea || inserted {
+		t.Error("zero area polygon not rejected", err, inserted)
+	}
+	if len(inserter.rows) != 0 {
+		t.Error("zero area polygon inserted", inserter.rows)
+	}
+}